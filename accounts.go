@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// accountConfig the credentials used for the domains of one GoDaddy account.
+// An entry without domains is the default one, unless the accounts are discovered.
+type accountConfig struct {
+	Domains []string `json:"domains,omitempty"`
+
+	APIKeyRef            *SecretKeyRef     `json:"apiKeyRef,omitempty"`
+	APISecretRef         *SecretKeyRef     `json:"apiSecretRef,omitempty"`
+	APIKeySecretRef      SecretKeySelector `json:"apiKeySecretRef"`
+	CredentialsSecretRef *SecretKeyRef     `json:"credentialsSecretRef,omitempty"`
+	ShopperID            string            `json:"shopperId,omitempty"`
+	ShopperIDSecretRef   *SecretKeyRef     `json:"shopperIdSecretRef,omitempty"`
+}
+
+// withAccount returns the config using the credentials of the account.
+// The shopper id of the config is kept when the account sets none.
+func (c godaddyDNSProviderConfig) withAccount(account accountConfig) godaddyDNSProviderConfig {
+	c.Accounts = nil
+	c.APIKeyRef = account.APIKeyRef
+	c.APISecretRef = account.APISecretRef
+	c.APIKeySecretRef = account.APIKeySecretRef
+	c.CredentialsSecretRef = account.CredentialsSecretRef
+
+	if account.ShopperID != "" || account.ShopperIDSecretRef != nil {
+		c.ShopperID = account.ShopperID
+		c.ShopperIDSecretRef = account.ShopperIDSecretRef
+	}
+
+	return c
+}
+
+// validateAccounts checks the entries of accounts
+func (c godaddyDNSProviderConfig) validateAccounts() error {
+	if len(c.Accounts) == 0 {
+		if c.DiscoverAccounts {
+			return fmt.Errorf("discoverAccounts requires accounts")
+		}
+
+		return nil
+	}
+
+	if !c.usesAmbientCredentials() {
+		return fmt.Errorf("accounts can't be used with apiKeyRef, apiSecretRef, apiKeySecretRef or credentialsSecretRef, move the credentials to an entry")
+	}
+
+	defaults := 0
+
+	for i, account := range c.Accounts {
+		if len(account.Domains) == 0 {
+			defaults++
+		}
+
+		for _, domain := range account.Domains {
+			if normalizeDomain(domain) == "" {
+				return fmt.Errorf("accounts[%d]: empty domain", i)
+			}
+		}
+
+		if err := c.withAccount(account).validateCredentials(); err != nil {
+			return fmt.Errorf("accounts[%d]: %v", i, err)
+		}
+	}
+
+	if defaults > 1 && !c.DiscoverAccounts {
+		return fmt.Errorf("accounts has %d entries without domains, only one default entry is allowed", defaults)
+	}
+
+	return nil
+}
+
+// normalizeDomain returns the domain in lower case without leading and trailing dots
+func normalizeDomain(domain string) string {
+	return strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
+
+// matchAccount returns the index of the entry of accounts whose domain is the longest
+// suffix of fqdn and true, or the index of the default entry and false when none matches,
+// -1 if there is no default entry
+func (c godaddyDNSProviderConfig) matchAccount(fqdn string) (int, bool) {
+	name := normalizeDomain(fqdn)
+	selected := -1
+	longest := 0
+	fallback := -1
+
+	for i, account := range c.Accounts {
+		if len(account.Domains) == 0 && fallback < 0 {
+			fallback = i
+		}
+
+		for _, domain := range account.Domains {
+			domain = normalizeDomain(domain)
+
+			if (name == domain || strings.HasSuffix(name, "."+domain)) && len(domain) > longest {
+				selected = i
+				longest = len(domain)
+			}
+		}
+	}
+
+	if selected >= 0 {
+		return selected, true
+	}
+
+	return fallback, false
+}
+
+// selectAccount returns the config using the credentials of the entry of accounts whose domain
+// is the longest suffix of fqdn, or of the default entry. The config is returned as is without accounts.
+func (c godaddyDNSProviderConfig) selectAccount(fqdn string) (godaddyDNSProviderConfig, error) {
+	if len(c.Accounts) == 0 {
+		return c, nil
+	}
+
+	selected, _ := c.matchAccount(fqdn)
+	if selected < 0 {
+		return c, fmt.Errorf("no entry of accounts matches %s, add one of its domains to an entry or add an entry without domains as default", fqdn)
+	}
+
+	klog.V(4).Infof("Use accounts[%d] for %s", selected, fqdn)
+
+	return c.withAccount(c.Accounts[selected]), nil
+}
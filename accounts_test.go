@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Fred78290/cert-manager-webhook-godaddy/godaddy"
+	extapi "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestSelectAccount(t *testing.T) {
+	config := `{"accounts":[
+		{"apiKeySecretRef":{"name":"default"}},
+		{"domains":["example.com","example.org"],"apiKeySecretRef":{"name":"first"},"shopperId":"1"},
+		{"domains":["Team.Example.com."],"apiKeySecretRef":{"name":"second"}}
+	],"shopperId":"0"}`
+
+	cfg, err := loadConfig(&extapi.JSON{Raw: []byte(config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		fqdn      string
+		expected  string
+		shopperID string
+	}{
+		{"_acme-challenge.example.com.", "first", "1"},
+		{"_acme-challenge.www.example.org.", "first", "1"},
+		{"_acme-challenge.team.example.com.", "second", "0"},
+		{"_acme-challenge.a.team.example.com.", "second", "0"},
+		{"_acme-challenge.notexample.com.", "default", "0"},
+		{"_acme-challenge.example.net.", "default", "0"},
+	}
+
+	for _, test := range tests {
+		selected, err := cfg.selectAccount(test.fqdn)
+		if err != nil {
+			t.Fatalf("%s: %v", test.fqdn, err)
+		}
+
+		if name := *selected.APIKeySecretRef.Name; name != test.expected || selected.ShopperID != test.shopperID {
+			t.Errorf("%s: expected %s with shopper %s, got: %s with shopper %s", test.fqdn, test.expected, test.shopperID, name, selected.ShopperID)
+		}
+	}
+
+	cfg.Accounts = cfg.Accounts[1:]
+
+	if _, err := cfg.selectAccount("_acme-challenge.example.net."); err == nil || !strings.Contains(err.Error(), "example.net") {
+		t.Fatalf("expected an error when no entry matches, got: %v", err)
+	}
+}
+
+func TestAccountsInvalid(t *testing.T) {
+	for _, config := range []string{
+		`{"accounts":[{"apiKeySecretRef":{"name":"a"}},{"apiKeySecretRef":{"name":"b"}}]}`,
+		`{"accounts":[{"domains":[""],"apiKeySecretRef":{"name":"a"}}]}`,
+		`{"accounts":[{"domains":["example.com"],"apiKeyRef":{"name":"a"}}]}`,
+		`{"accounts":[{"domains":["example.com"],"apiKeySecretRef":{"name":"a"}}],"apiKeySecretRef":{"name":"b"}}`,
+		`{"discoverAccounts":true}`,
+	} {
+		if _, err := loadConfig(&extapi.JSON{Raw: []byte(config)}); err == nil {
+			t.Errorf("config %s: expected an error", config)
+		}
+	}
+}
+
+func TestAccountsClient(t *testing.T) {
+	stubSolver(t, nil)
+
+	solver := &godaddyDNSProviderSolver{}
+	config := `{"accounts":[
+		{"domains":["example.com"],"apiKeySecretRef":{"key":"key-1","secret":"secret-1"}},
+		{"domains":["example.org"],"apiKeySecretRef":{"key":"key-2","secret":"secret-2"}}
+	]}`
+
+	for i, zone := range []string{"example.com", "example.org"} {
+		ch := newChallengeRequest("_acme-challenge."+zone+".", zone+".", "token", config)
+
+		cfg, err := loadConfig(ch.Config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client, _, err := solver.getClient(cfg, ch)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := godaddy.Fingerprint(fmt.Sprintf("key-%d", i+1)); client.Account() != expected {
+			t.Errorf("%s: expected the account %s, got: %s", zone, expected, client.Account())
+		}
+	}
+}
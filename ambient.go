@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ambientFile the last content read from a credential file
+type ambientFile struct {
+	modTime time.Time
+	size    int64
+	content string
+}
+
+// ambientCredentials the default API key and secret of the webhook, used by the issuers
+// without credentials of their own when cert-manager allows ambient credentials.
+// The files, e.g. mounted from a projected volume, take precedence over the environment
+// and are read again when they change.
+type ambientCredentials struct {
+	apiKey     string
+	apiSecret  string
+	keyFile    string
+	secretFile string
+	mu         sync.Mutex
+	files      map[string]*ambientFile
+}
+
+// Get returns the ambient API key and secret
+func (a *ambientCredentials) Get() (string, string, error) {
+	if a == nil {
+		return "", "", fmt.Errorf("no ambient credentials configured")
+	}
+
+	apiKey, err := a.value(a.apiKey, a.keyFile)
+	if err != nil {
+		return "", "", err
+	}
+
+	apiSecret, err := a.value(a.apiSecret, a.secretFile)
+	if err != nil {
+		return "", "", err
+	}
+
+	if apiKey == "" || apiSecret == "" {
+		return "", "", fmt.Errorf("no ambient credentials configured, set GODADDY_API_KEY and GODADDY_API_SECRET or --api-key-file and --api-secret-file")
+	}
+
+	return apiKey, apiSecret, nil
+}
+
+func (a *ambientCredentials) value(env, path string) (string, error) {
+	if path == "" {
+		return env, nil
+	}
+
+	return a.read(path)
+}
+
+// read returns the trimmed content of the file, read again only when its size or modification time changed
+func (a *ambientCredentials) read(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read ambient credentials; %v", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if file, ok := a.files[path]; ok && file.modTime.Equal(info.ModTime()) && file.size == info.Size() {
+		return file.content, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read ambient credentials; %v", err)
+	}
+
+	if a.files == nil {
+		a.files = map[string]*ambientFile{}
+	}
+
+	a.files[path] = &ambientFile{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		content: strings.TrimSpace(string(content)),
+	}
+
+	return a.files[path].content, nil
+}
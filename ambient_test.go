@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAmbientCredentialsFiles(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	secretFile := filepath.Join(dir, "secret")
+
+	write := func(path, content string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+
+	write(keyFile, "key-1\n", now)
+	write(secretFile, "secret-1\n", now)
+
+	ambient := &ambientCredentials{apiKey: "env-key", apiSecret: "env-secret", keyFile: keyFile, secretFile: secretFile}
+
+	if apiKey, apiSecret, err := ambient.Get(); err != nil || apiKey != "key-1" || apiSecret != "secret-1" {
+		t.Fatalf("unexpected credentials: %s:%s, %v", apiKey, apiSecret, err)
+	}
+
+	write(keyFile, "key-2", now.Add(time.Minute))
+	write(secretFile, "secret-2", now.Add(time.Minute))
+
+	if apiKey, apiSecret, err := ambient.Get(); err != nil || apiKey != "key-2" || apiSecret != "secret-2" {
+		t.Fatalf("expected the rotated credentials, got: %s:%s, %v", apiKey, apiSecret, err)
+	}
+}
+
+func TestAmbientCredentialsAllowed(t *testing.T) {
+	api := newFakeGoDaddy(t)
+
+	solver := &godaddyDNSProviderSolver{
+		ambient: &ambientCredentials{apiKey: "key", apiSecret: "secret"},
+	}
+
+	cfg := godaddyDNSProviderConfig{APIURL: api.URL}
+	ch := newChallengeRequest("_acme-challenge.example.com.", "example.com.", "token", "{}")
+
+	if _, _, err := solver.getClient(cfg, ch); err == nil || !strings.Contains(err.Error(), "ambient") {
+		t.Fatalf("expected the ambient credentials to be refused, got: %v", err)
+	}
+
+	ch.AllowAmbientCredentials = true
+
+	if _, _, err := solver.getClient(cfg, ch); err != nil {
+		t.Fatal(err)
+	}
+
+	solver.ambient = &ambientCredentials{}
+
+	if _, _, err := solver.getClient(cfg, ch); err == nil || !strings.Contains(err.Error(), "GODADDY_API_KEY") {
+		t.Fatalf("expected an error on missing ambient credentials, got: %v", err)
+	}
+}
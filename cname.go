@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cert-manager/cert-manager/pkg/issuer/acme/dns/util"
+	"github.com/miekg/dns"
+	"k8s.io/klog/v2"
+)
+
+// maxCNAMEDepth bounds the length of a followed CNAME chain
+const maxCNAMEDepth = 10
+
+// followCNAME returns the final target of the CNAME chain starting at fqdn,
+// fqdn itself when it's not an alias. Loops and chains longer than
+// maxCNAMEDepth are rejected.
+func followCNAME(fqdn string, nameservers []string) (string, error) {
+	visited := make(map[string]bool)
+	current := util.ToFqdn(fqdn)
+
+	for depth := 0; ; depth++ {
+		key := strings.ToLower(current)
+
+		if visited[key] {
+			return "", fmt.Errorf("CNAME loop detected at %s in the chain from %s", current, fqdn)
+		}
+
+		visited[key] = true
+
+		msg, err := util.DNSQuery(current, dns.TypeCNAME, nameservers, true)
+		if err != nil {
+			return "", fmt.Errorf("unable to resolve CNAME of %s: %v", current, err)
+		}
+
+		if msg.Rcode != dns.RcodeSuccess && msg.Rcode != dns.RcodeNameError {
+			return "", fmt.Errorf("unable to resolve CNAME of %s: %s", current, dns.RcodeToString[msg.Rcode])
+		}
+
+		target := ""
+
+		for _, rr := range msg.Answer {
+			if cname, ok := rr.(*dns.CNAME); ok && strings.EqualFold(cname.Hdr.Name, current) {
+				target = cname.Target
+				break
+			}
+		}
+
+		if target == "" {
+			return current, nil
+		}
+
+		if depth >= maxCNAMEDepth {
+			return "", fmt.Errorf("CNAME chain from %s is longer than %d", fqdn, maxCNAMEDepth)
+		}
+
+		klog.V(2).Infof("Follow CNAME %s -> %s", current, target)
+
+		current = target
+	}
+}
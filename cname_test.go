@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFollowCNAME(t *testing.T) {
+	server := newFakeDNS(t)
+	server.add(t,
+		"_acme-challenge.app.customer.com. 300 IN CNAME app.acme-validation.example.com.",
+		"app.acme-validation.example.com. 300 IN CNAME final.acme-validation.example.com.",
+		"loop-a.example.com. 300 IN CNAME loop-b.example.com.",
+		"loop-b.example.com. 300 IN CNAME loop-a.example.com.",
+	)
+
+	for i := 0; i <= maxCNAMEDepth; i++ {
+		server.add(t, fmt.Sprintf("chain-%d.example.com. 300 IN CNAME chain-%d.example.com.", i, i+1))
+	}
+
+	nameservers := []string{server.Addr}
+
+	if target, err := followCNAME("_acme-challenge.app.customer.com.", nameservers); err != nil || target != "final.acme-validation.example.com." {
+		t.Fatalf("unexpected target: %s, error: %v", target, err)
+	}
+
+	if target, err := followCNAME("_acme-challenge.example.com", nameservers); err != nil || target != "_acme-challenge.example.com." {
+		t.Fatalf("expected the fqdn itself, got: %s, error: %v", target, err)
+	}
+
+	if _, err := followCNAME("loop-a.example.com.", nameservers); err == nil {
+		t.Fatal("expected a loop error")
+	}
+
+	if _, err := followCNAME("chain-0.example.com.", nameservers); err == nil {
+		t.Fatal("expected a depth error")
+	}
+}
+
+func TestPresentFollowsCNAME(t *testing.T) {
+	stubSolver(t, nil)
+
+	api := newFakeGoDaddy(t)
+	api.domains = []string{"example.com"}
+
+	server := newFakeDNS(t)
+	server.add(t, "_acme-challenge.app.customer.com. 300 IN CNAME app.acme-validation.example.com.")
+
+	nameservers := recursiveNameservers
+
+	t.Cleanup(func() {
+		recursiveNameservers = nameservers
+	})
+
+	recursiveNameservers = []string{server.Addr}
+
+	solver := &godaddyDNSProviderSolver{}
+	config := fmt.Sprintf(`{"apiKeySecretRef":{"key":"key","secret":"secret"},"ttl":600,"apiURL":%q,"zoneResolution":"api","followCNAME":true}`, api.URL)
+	ch := newChallengeRequest("_acme-challenge.app.customer.com.", "customer.com.", "key", config)
+
+	if err := solver.Present(ch); err != nil {
+		t.Fatal(err)
+	}
+
+	if records := api.get("example.com", "TXT", "app.acme-validation"); len(records) != 1 {
+		t.Fatalf("expected the TXT record at the CNAME target, got: %d", len(records))
+	}
+
+	if err := solver.CleanUp(ch); err != nil {
+		t.Fatal(err)
+	}
+
+	if records := api.get("example.com", "TXT", "app.acme-validation"); len(records) != 0 {
+		t.Fatalf("expected the TXT record to be cleaned, got: %d", len(records))
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Fred78290/cert-manager-webhook-godaddy/godaddy"
+	extapi "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestConfigEndpoint(t *testing.T) {
+	tests := []struct {
+		config   string
+		expected string
+	}{
+		{`{}`, godaddy.OTEURL},
+		{`{"production":true}`, godaddy.ProductionURL},
+		{`{"environment":"ote","production":true}`, godaddy.OTEURL},
+		{`{"environment":"production"}`, godaddy.ProductionURL},
+		{`{"apiURL":"https://gateway.internal/godaddy","production":true}`, "https://gateway.internal/godaddy"},
+		{`{"environment":"custom","apiURL":"http://127.0.0.1:8080"}`, "http://127.0.0.1:8080"},
+	}
+
+	for _, test := range tests {
+		cfg, err := loadConfig(&extapi.JSON{Raw: []byte(test.config)})
+		if err != nil {
+			t.Errorf("config %s: %v", test.config, err)
+		} else if url := cfg.goDaddyURL(); url != test.expected {
+			t.Errorf("config %s: expected %s, got: %s", test.config, test.expected, url)
+		}
+	}
+}
+
+func TestConfigEndpointInvalid(t *testing.T) {
+	for _, config := range []string{
+		`{"environment":"staging"}`,
+		`{"environment":"custom"}`,
+		`{"environment":"ote","apiURL":"https://gateway.internal"}`,
+		`{"apiURL":"gateway.internal"}`,
+		`{"apiURL":"ftp://gateway.internal"}`,
+		`{"apiURL":"https://gateway.internal?key=value"}`,
+	} {
+		if _, err := loadConfig(&extapi.JSON{Raw: []byte(config)}); err == nil {
+			t.Errorf("config %s: expected an error", config)
+		}
+	}
+}
+
+func TestInlineCredentials(t *testing.T) {
+	solver := &godaddyDNSProviderSolver{}
+	cfg := godaddyDNSProviderConfig{APIKeySecretRef: SecretKeySelector{Key: "key", Secret: "secret"}}
+
+	stubSolver(t, nil)
+
+	*allowInlineCredentials = false
+
+	if _, _, err := solver.getAPIKey(cfg, "default"); err == nil || !strings.Contains(err.Error(), "--allow-inline-credentials") {
+		t.Fatalf("expected inline credentials to be refused, got: %v", err)
+	}
+
+	*allowInlineCredentials = true
+
+	used, _ := testutil.GetCounterMetricValue(inlineCredentialsTotal)
+
+	if apiKey, apiSecret, err := solver.getAPIKey(cfg, "default"); err != nil || *apiKey != "key" || *apiSecret != "secret" {
+		t.Fatalf("expected inline credentials to be accepted, got: %v", err)
+	}
+
+	if got, _ := testutil.GetCounterMetricValue(inlineCredentialsTotal); got != used+1 {
+		t.Fatalf("expected the use of inline credentials to be counted")
+	}
+}
+
+func TestAPICredentialRefs(t *testing.T) {
+	tests := []struct {
+		config string
+		key    SecretKeyRef
+		secret SecretKeyRef
+	}{
+		{
+			`{"apiKeyRef":{"name":"godaddy-key"},"apiSecretRef":{"name":"godaddy-secret","key":"value","namespace":"dns"}}`,
+			SecretKeyRef{Name: "godaddy-key", Key: "key"},
+			SecretKeyRef{Name: "godaddy-secret", Key: "value", Namespace: "dns"},
+		},
+		{
+			`{"apiKeySecretRef":{"name":"godaddy-api-key","key":"apikey","secret":"apisecret"}}`,
+			SecretKeyRef{Name: "godaddy-api-key", Key: "apikey"},
+			SecretKeyRef{Name: "godaddy-api-key", Key: "apisecret"},
+		},
+		{
+			`{"apiKeySecretRef":{"name":"godaddy-api-key"}}`,
+			SecretKeyRef{Name: "godaddy-api-key", Key: "key"},
+			SecretKeyRef{Name: "godaddy-api-key", Key: "secret"},
+		},
+	}
+
+	for _, test := range tests {
+		cfg, err := loadConfig(&extapi.JSON{Raw: []byte(test.config)})
+		if err != nil {
+			t.Fatalf("config %s: %v", test.config, err)
+		}
+
+		key, secret, ok := cfg.apiCredentialRefs()
+		if !ok || key.SecretKeyRef != test.key || secret.SecretKeyRef != test.secret {
+			t.Errorf("config %s: unexpected references %v and %v", test.config, key, secret)
+		}
+	}
+
+	for _, config := range []string{
+		`{"apiKeyRef":{"name":"godaddy-key"}}`,
+		`{"apiKeyRef":{"name":"godaddy-key"},"apiSecretRef":{"key":"secret"}}`,
+		`{"apiKeyRef":{"name":"godaddy-key"},"apiSecretRef":{"name":"godaddy-key"},"apiKeySecretRef":{"name":"godaddy-api-key"}}`,
+	} {
+		if _, err := loadConfig(&extapi.JSON{Raw: []byte(config)}); err == nil {
+			t.Errorf("config %s: expected an error", config)
+		}
+	}
+}
+
+func TestCredentialSource(t *testing.T) {
+	tests := []struct {
+		config string
+		source string
+	}{
+		{`{}`, "the ambient credentials of the webhook"},
+		{`{"apiKeyRef":{"name":"godaddy-key"},"apiSecretRef":{"name":"godaddy-secret"}}`, "apiKeyRef and apiSecretRef"},
+		{`{"credentialsSecretRef":{"name":"godaddy"}}`, "credentialsSecretRef"},
+		{`{"apiKeySecretRef":{"name":"godaddy-api-key"}}`, "apiKeySecretRef"},
+		{`{"apiKeySecretRef":{"key":"key","secret":"secret"}}`, "the inline apiKeySecretRef"},
+		{`{"accounts":[{"apiKeySecretRef":{"name":"a"}},{"domains":["example.com"],"credentialsSecretRef":{"name":"b"}}]}`, "credentialsSecretRef of accounts[1]"},
+		{`{"discoverAccounts":true,"accounts":[{"apiKeySecretRef":{"name":"a"}},{"apiKeySecretRef":{"name":"b"}}]}`, "the entry of accounts owning the zone"},
+	}
+
+	unauthorized := &godaddy.APIError{StatusCode: http.StatusUnauthorized}
+
+	for _, test := range tests {
+		cfg, err := loadConfig(&extapi.JSON{Raw: []byte(test.config)})
+		if err != nil {
+			t.Fatalf("config %s: %v", test.config, err)
+		}
+
+		source := cfg.credentialSource("_acme-challenge.example.com.")
+		if !strings.HasPrefix(source, test.source) {
+			t.Errorf("config %s: expected the source %s, got: %s", test.config, test.source, source)
+		}
+
+		if err := explainError(unauthorized, "example.com", source); !strings.Contains(err.Error(), "read from "+source) {
+			t.Errorf("config %s: expected the error to name the source, got: %v", test.config, err)
+		}
+	}
+}
+
+func TestSplitAPICredentials(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	key := newTestSecret("default", "godaddy-key", "key")
+	secret := newTestSecret("default", "godaddy-secret", "")
+	secret.Data = map[string][]byte{"secret": []byte("secret")}
+
+	solver := &godaddyDNSProviderSolver{
+		secrets: newSecretCache(fake.NewSimpleClientset(key, secret), nil, stopCh),
+	}
+
+	cfg, err := loadConfig(&extapi.JSON{Raw: []byte(`{"apiKeyRef":{"name":"godaddy-key"},"apiSecretRef":{"name":"godaddy-secret"}}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apiKey, apiSecret, err := solver.getAPIKey(cfg, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *apiKey != "key" || *apiSecret != "secret" {
+		t.Fatalf("unexpected credentials read from two secrets")
+	}
+
+	cfg.APISecretRef.Key = "missing"
+
+	if _, _, err := solver.getAPIKey(cfg, "default"); err == nil || !strings.Contains(err.Error(), "apiSecretRef: entry missing") {
+		t.Fatalf("expected an error naming the reference, got: %v", err)
+	}
+}
+
+func TestShopperIDSecretRef(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	sec := newTestSecret("dns", "godaddy-shopper", "")
+	sec.Data = map[string][]byte{"shopper": []byte(" 123456\n")}
+
+	solver := &godaddyDNSProviderSolver{
+		secrets: newSecretCache(fake.NewSimpleClientset(sec), nil, stopCh),
+	}
+
+	cfg, err := loadConfig(&extapi.JSON{Raw: []byte(`{"apiKeySecretRef":{"name":"godaddy-api-key"},"shopperId":"1","shopperIdSecretRef":{"name":"godaddy-shopper","key":"shopper","namespace":"dns"}}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if shopperID, err := solver.getShopperID(cfg, "cert-manager"); err != nil || shopperID != "123456" {
+		t.Fatalf("expected the shopper id of the secret, got: %s, error: %v", shopperID, err)
+	}
+
+	for _, config := range []string{
+		`{"shopperIdSecretRef":{"name":"godaddy-shopper"}}`,
+		`{"shopperIdSecretRef":{"key":"shopper"}}`,
+	} {
+		if _, err := loadConfig(&extapi.JSON{Raw: []byte(config)}); err == nil {
+			t.Errorf("config %s: expected an error", config)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// defaultCredentialsEntry the entry of the Secret holding the credentials when credentialsSecretRef has no key
+const defaultCredentialsEntry = "credentials"
+
+// apiCredentials the credentials of a GoDaddy account
+type apiCredentials struct {
+	APIKey    string `json:"key"`
+	APISecret string `json:"secret"`
+	ShopperID string `json:"shopperId,omitempty"`
+}
+
+// parseCredentials decodes a JSON document {"key":"…","secret":"…","shopperId":"…"}
+// or a `key:secret` pair. The errors never contain the value, it holds the secret.
+func parseCredentials(value []byte) (apiCredentials, error) {
+	var creds apiCredentials
+
+	value = bytes.TrimSpace(value)
+
+	if bytes.HasPrefix(value, []byte("{")) {
+		decoder := json.NewDecoder(bytes.NewReader(value))
+		decoder.DisallowUnknownFields()
+
+		if err := decoder.Decode(&creds); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+
+			switch {
+			case errors.As(err, &syntaxErr):
+				return creds, fmt.Errorf("malformed JSON credentials at offset %d", syntaxErr.Offset)
+			case errors.As(err, &typeErr):
+				return creds, fmt.Errorf("malformed JSON credentials, field %s must be a string", typeErr.Field)
+			case strings.HasPrefix(err.Error(), "json: unknown field "):
+				// The name of the field is content too, a secret may be used as a key by mistake
+				return creds, fmt.Errorf("malformed JSON credentials, unexpected field, expected key, secret and shopperId")
+			default:
+				return creds, fmt.Errorf("malformed JSON credentials")
+			}
+		}
+
+		if decoder.More() {
+			return creds, fmt.Errorf("malformed JSON credentials, unexpected data after the document")
+		}
+	} else if key, secret, ok := strings.Cut(string(value), ":"); ok {
+		creds.APIKey = strings.TrimSpace(key)
+		creds.APISecret = strings.TrimSpace(secret)
+	} else {
+		return creds, fmt.Errorf("malformed credentials, expected a JSON document or key:secret")
+	}
+
+	if creds.APIKey == "" || creds.APISecret == "" {
+		return creds, fmt.Errorf("malformed credentials, the key and the secret are required")
+	}
+
+	return creds, nil
+}
+
+// getCredentialsBlob returns the credentials stored in the Secret entry referenced by credentialsSecretRef
+func (c *godaddyDNSProviderSolver) getCredentialsBlob(cfg godaddyDNSProviderConfig, resourceNamespace string) (apiCredentials, error) {
+	ref := *cfg.CredentialsSecretRef
+
+	if ref.Key == "" {
+		ref.Key = defaultCredentialsEntry
+	}
+
+	sec, location, err := c.getReferencedSecret("credentialsSecretRef", ref.Name, ref.Namespace, resourceNamespace)
+	if err != nil {
+		return apiCredentials{}, err
+	}
+
+	value, ok := sec.Data[ref.Key]
+	if !ok {
+		return apiCredentials{}, location.explain(fmt.Errorf("credentialsSecretRef: entry %s not found in secret \"%s/%s\"", ref.Key, location.namespace, sec.Name))
+	}
+
+	creds, err := parseCredentials(value)
+	if err != nil {
+		return creds, fmt.Errorf("credentialsSecretRef: entry %s of secret \"%s/%s\": %v", ref.Key, location.namespace, sec.Name, err)
+	}
+
+	klog.V(4).Infof("GoDaddy use key %s from secret \"%s/%s\"", fingerprint(creds.APIKey), location.namespace, sec.Name)
+
+	return creds, nil
+}
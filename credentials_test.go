@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	extapi "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseCredentials(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected apiCredentials
+	}{
+		{`{"key":"k","secret":"s","shopperId":"123"}`, apiCredentials{APIKey: "k", APISecret: "s", ShopperID: "123"}},
+		{" {\"key\":\"k\",\"secret\":\"s\"}\n", apiCredentials{APIKey: "k", APISecret: "s"}},
+		{"k:s:with:colons\n", apiCredentials{APIKey: "k", APISecret: "s:with:colons"}},
+	}
+
+	for _, test := range tests {
+		creds, err := parseCredentials([]byte(test.value))
+		if err != nil {
+			t.Errorf("value %q: %v", test.value, err)
+		} else if creds != test.expected {
+			t.Errorf("value %q: unexpected credentials %v", test.value, creds)
+		}
+	}
+}
+
+func TestParseCredentialsMalformed(t *testing.T) {
+	const secret = "top-secret-value"
+
+	for _, value := range []string{
+		`{"key":"k","secret":"` + secret + `"`,
+		`{"key":"k","secret":["` + secret + `"]}`,
+		`{"key":"k","secret":"` + secret + `","password":"` + secret + `"}`,
+		`{"key":"k","secret":"s","` + secret + `":""}`,
+		`{"key":"k","secret":"` + secret + `"}x`,
+		`{"key":"` + secret + `"}`,
+		`{"key":"k","secret":` + secret + `}`,
+		secret,
+		":" + secret,
+	} {
+		_, err := parseCredentials([]byte(value))
+		if err == nil {
+			t.Errorf("value %q: expected an error", value)
+		} else if strings.Contains(err.Error(), secret) || strings.Contains(err.Error(), "top") {
+			t.Errorf("value %q: the error discloses the content: %v", value, err)
+		}
+	}
+}
+
+func TestCredentialsSecretRef(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	sec := newTestSecret("default", "godaddy-credentials", "")
+	sec.Data = map[string][]byte{"credentials": []byte(`{"key":"key","secret":"secret","shopperId":"123"}`)}
+
+	solver := &godaddyDNSProviderSolver{
+		secrets: newSecretCache(fake.NewSimpleClientset(sec), nil, stopCh),
+	}
+
+	ch := newChallengeRequest("_acme-challenge.example.com.", "example.com.", "token", `{"credentialsSecretRef":{"name":"godaddy-credentials"}}`)
+
+	cfg, err := loadConfig(ch.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, _, err := solver.getClient(cfg, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if client.ShopperID() != "123" {
+		t.Fatalf("expected the shopper id of the credentials, got: %s", client.ShopperID())
+	}
+
+	cfg.ShopperID = "456"
+
+	if client, _, err = solver.getClient(cfg, ch); err != nil || client.ShopperID() != "456" {
+		t.Fatalf("expected the shopper id of the config, got: %v", err)
+	}
+
+	if _, err := loadConfig(&extapi.JSON{Raw: []byte(`{"credentialsSecretRef":{"name":"godaddy-credentials"},"apiKeySecretRef":{"name":"godaddy-api-key"}}`)}); err == nil {
+		t.Fatal("expected credentialsSecretRef and apiKeySecretRef to be exclusive")
+	}
+}
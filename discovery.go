@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fred78290/cert-manager-webhook-godaddy/godaddy"
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	"github.com/cert-manager/cert-manager/pkg/issuer/acme/dns/util"
+	"k8s.io/klog/v2"
+)
+
+// ownerCache caches the account owning each domain when the accounts are discovered.
+// The zero value is ready to use.
+type ownerCache struct {
+	mu      sync.Mutex
+	entries map[string]ownerCacheEntry
+}
+
+type ownerCacheEntry struct {
+	account string
+	expires time.Time
+}
+
+func ownerCacheKey(baseURL, domain string) string {
+	return baseURL + "/" + domain
+}
+
+// get returns the account owning the domain, false if unknown or expired
+func (o *ownerCache) get(baseURL, domain string) (string, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entry, found := o.entries[ownerCacheKey(baseURL, domain)]
+	if !found || time.Now().After(entry.expires) {
+		return "", false
+	}
+
+	return entry.account, true
+}
+
+func (o *ownerCache) set(baseURL, domain, account string, ttl time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.entries == nil {
+		o.entries = map[string]ownerCacheEntry{}
+	}
+
+	o.entries[ownerCacheKey(baseURL, domain)] = ownerCacheEntry{
+		account: account,
+		expires: time.Now().Add(ttl),
+	}
+}
+
+// invalidate forgets the owners of name and of its parent domains
+func (o *ownerCache) invalidate(baseURL, name string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, domain := range parentDomains(name) {
+		delete(o.entries, ownerCacheKey(baseURL, domain))
+	}
+}
+
+// parentDomains returns the domain and its parents having at least two labels, the longest first
+func parentDomains(name string) []string {
+	var domains []string
+
+	labels := strings.Split(normalizeDomain(name), ".")
+
+	for i := 0; i < len(labels)-1; i++ {
+		domains = append(domains, strings.Join(labels[i:], "."))
+	}
+
+	return domains
+}
+
+// ownedDomain reports whether the domain is registered in the account of client and not transferred away
+func ownedDomain(ctx context.Context, client *godaddy.Client, domain string) (bool, error) {
+	result, err := client.GetDomain(ctx, domain)
+	if err != nil {
+		if godaddy.IsNotFound(err) || godaddy.IsAccessDenied(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return managedDomain(*result), nil
+}
+
+// discoveryZone returns the zone hosting the TXT record of the challenge,
+// the zone of the CNAME target with followCNAME.
+func discoveryZone(cfg godaddyDNSProviderConfig, ch *v1alpha1.ChallengeRequest) (string, error) {
+	fqdn, zone, err := recordTarget(cfg, ch)
+	if err != nil {
+		return "", err
+	}
+
+	if zone == "" {
+		if cfg.ZoneResolution == zoneResolutionStatic {
+			return findStaticZone(cfg, fqdn)
+		}
+
+		if zone, err = findZoneByFqdn(fqdn, recursiveNameservers); err != nil {
+			return "", fmt.Errorf("unable to find the zone of %s to discover its account; %v", fqdn, err)
+		}
+	}
+
+	return util.UnFqdn(zone), nil
+}
+
+// discoverClient returns the client of the entry of accounts owning the zone of the challenge,
+// and that zone. An entry whose domains match the challenge is used first, the zone is then empty.
+// Otherwise each entry is asked in turn with GET /v1/domains/{domain}. The owners are cached for --account-cache-ttl.
+func (c *godaddyDNSProviderSolver) discoverClient(cfg godaddyDNSProviderConfig, ch *v1alpha1.ChallengeRequest) (*godaddy.Client, string, error) {
+	if selected, matched := cfg.matchAccount(ch.ResolvedFQDN); matched {
+		client, err := c.accountClient(cfg.withAccount(cfg.Accounts[selected]), ch)
+
+		return client, "", err
+	}
+
+	zone, err := discoveryZone(cfg, ch)
+	if err != nil {
+		return nil, "", err
+	}
+
+	clients := make([]*godaddy.Client, len(cfg.Accounts))
+	errs := make([]error, len(cfg.Accounts))
+	failures := make([]string, 0, len(cfg.Accounts))
+
+	// The clients are built once, a failure is reported once
+	client := func(i int) (*godaddy.Client, error) {
+		if clients[i] == nil && errs[i] == nil {
+			if clients[i], errs[i] = c.accountClient(cfg.withAccount(cfg.Accounts[i]), ch); errs[i] != nil {
+				failures = append(failures, fmt.Sprintf("accounts[%d]: %v", i, errs[i]))
+			}
+		}
+
+		return clients[i], errs[i]
+	}
+
+	ctx := NewContext(120)
+	defer ctx.cancel()
+
+	// The owners are keyed by client.BaseURL(), like forgetOwner does
+	for _, domain := range parentDomains(zone) {
+		for i := range cfg.Accounts {
+			if account, err := client(i); err == nil {
+				if owner, found := c.owners.get(account.BaseURL(), domain); found && account.Account() == owner {
+					return account, zone, nil
+				}
+			}
+		}
+
+		for i := range cfg.Accounts {
+			account, err := client(i)
+			if err != nil {
+				continue
+			}
+
+			owned, err := ownedDomain(ctx.ctx, account, domain)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("accounts[%d]: %v", i, err))
+				continue
+			}
+
+			if owned {
+				klog.Infof("Domain: %s is owned by accounts[%d], account: %s, shopper: %s", domain, i, account.Account(), account.ShopperID())
+
+				c.owners.set(account.BaseURL(), domain, account.Account(), *accountCacheTTL)
+
+				return account, zone, nil
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		return nil, zone, fmt.Errorf("no entry of accounts owns the zone %s; %s", zone, strings.Join(failures, "; "))
+	}
+
+	return nil, zone, fmt.Errorf("no entry of accounts owns the zone %s", zone)
+}
+
+// forgetOwner drops the cached owner of the discovered zone when the API reports the domain unknown
+// or forbidden to the account, e.g. after a transfer. It returns true when the owner was forgotten,
+// the caller discovers it again and retries.
+func (c *godaddyDNSProviderSolver) forgetOwner(cfg godaddyDNSProviderConfig, client *godaddy.Client, zone string, err error) bool {
+	if !cfg.DiscoverAccounts || zone == "" || !(godaddy.IsNotFound(err) || godaddy.IsAccessDenied(err)) {
+		return false
+	}
+
+	klog.Warningf("Forget the owner of zone: %s, account: %s, error: %v", zone, client.Account(), err)
+
+	c.owners.invalidate(client.BaseURL(), zone)
+
+	return true
+}
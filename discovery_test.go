@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParentDomains(t *testing.T) {
+	if domains := parentDomains("Sub.Example.co.uk."); !reflect.DeepEqual(domains, []string{"sub.example.co.uk", "example.co.uk", "co.uk"}) {
+		t.Fatalf("unexpected domains: %v", domains)
+	}
+}
+
+func TestDiscoverAccounts(t *testing.T) {
+	stubSolver(t, staticZone("example.com."))
+
+	t.Run("apiURL", func(t *testing.T) {
+		testDiscoverAccounts(t, "")
+	})
+
+	// The owner is forgotten whatever the form of apiURL
+	t.Run("apiURL with trailing slash", func(t *testing.T) {
+		testDiscoverAccounts(t, "/")
+	})
+}
+
+func testDiscoverAccounts(t *testing.T, suffix string) {
+	api := newFakeGoDaddy(t)
+	api.owners = map[string]string{"example.com": "key-2"}
+
+	solver := &godaddyDNSProviderSolver{}
+	config := fmt.Sprintf(`{"discoverAccounts":true,"apiURL":%q,"accounts":[
+		{"apiKeySecretRef":{"key":"key-1","secret":"secret-1"}},
+		{"apiKeySecretRef":{"key":"key-2","secret":"secret-2"}},
+		{"apiKeySecretRef":{"key":"key-3","secret":"secret-3"}}
+	]}`, api.URL+suffix)
+
+	present := func(zone string) error {
+		return solver.Present(newChallengeRequest("_acme-challenge."+zone+".", zone+".", "token", config))
+	}
+
+	domainCalls := func() int {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+
+		return api.domainCalls
+	}
+
+	if err := present("example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := domainCalls(); calls != 2 {
+		t.Fatalf("expected the first two accounts to be asked, got %d calls", calls)
+	}
+
+	if err := present("example.com"); err != nil || domainCalls() != 2 {
+		t.Fatalf("expected the owner to be cached, got %d calls, error: %v", domainCalls(), err)
+	}
+
+	// The domain is transferred to another account
+	api.mu.Lock()
+	api.owners["example.com"] = "key-3"
+	api.mu.Unlock()
+
+	// The former owner is refused, the new one is discovered in the same call
+	if err := present("example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := domainCalls(); calls != 5 {
+		t.Fatalf("expected the owner to be discovered again, got %d calls", calls)
+	}
+
+	api.mu.Lock()
+	api.owners["example.com"] = "key-1"
+	api.mu.Unlock()
+
+	if err := solver.CleanUp(newChallengeRequest("_acme-challenge.example.com.", "example.com.", "token", config)); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := domainCalls(); calls != 6 {
+		t.Fatalf("expected the owner to be discovered again on cleanup, got %d calls", calls)
+	}
+
+	if err := present("example.net"); err == nil || !strings.Contains(err.Error(), "no entry of accounts owns the zone example.net") {
+		t.Fatalf("expected an error on a domain owned by no account, got: %v", err)
+	}
+}
+
+func TestDiscoverAccountsFollowsCNAME(t *testing.T) {
+	stubSolver(t, staticZone("example.com."))
+
+	api := newFakeGoDaddy(t)
+	api.domains = []string{"example.com"}
+	api.owners = map[string]string{"example.com": "key-2"}
+
+	server := newFakeDNS(t)
+	server.add(t, "_acme-challenge.app.customer.com. 300 IN CNAME app.acme-validation.example.com.")
+
+	nameservers := recursiveNameservers
+
+	t.Cleanup(func() {
+		recursiveNameservers = nameservers
+	})
+
+	recursiveNameservers = []string{server.Addr}
+
+	solver := &godaddyDNSProviderSolver{}
+	config := fmt.Sprintf(`{"discoverAccounts":true,"followCNAME":true,"zoneResolution":"api","apiURL":%q,"accounts":[
+		{"apiKeySecretRef":{"key":"key-1","secret":"secret-1"}},
+		{"apiKeySecretRef":{"key":"key-2","secret":"secret-2"}},
+		{"apiKeySecretRef":{"key":"key-3","secret":"secret-3"}}
+	]}`, api.URL)
+
+	// The owner of the CNAME target zone is discovered, not the one of customer.com
+	if err := solver.Present(newChallengeRequest("_acme-challenge.app.customer.com.", "customer.com.", "key", config)); err != nil {
+		t.Fatal(err)
+	}
+
+	if records := api.get("example.com", "TXT", "app.acme-validation"); len(records) != 1 {
+		t.Fatalf("expected the TXT record at the CNAME target, got: %d", len(records))
+	}
+}
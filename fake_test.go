@@ -0,0 +1,347 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Fred78290/cert-manager-webhook-godaddy/godaddy"
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	"github.com/miekg/dns"
+	extapi "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// fakeGoDaddy emulates the records endpoints of the GoDaddy domains API.
+// Each call is slowed down to make the read-modify-write races visible.
+// When owners is set, a domain is only served to the API key owning it.
+// A revoked API key is rejected with 401. The responses of the next lostDeletes
+// DELETE calls are lost: they're applied but answered with 503.
+// The domains are ACTIVE unless set otherwise in statuses.
+type fakeGoDaddy struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	records      map[string][]godaddy.DNSRecord
+	domains      []string
+	domainsCalls int
+	owners       map[string]string
+	domainCalls  int
+	revoked      map[string]bool
+	calls        []string
+	lostDeletes  int
+	statuses     map[string]string
+}
+
+func newFakeGoDaddy(t *testing.T) *fakeGoDaddy {
+	f := &fakeGoDaddy{
+		records: make(map[string][]godaddy.DNSRecord),
+	}
+
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+// stubSolver disables the API rate limit, allows the inline credentials and, when findZone is set,
+// replaces findZoneByFqdn. The globals are restored when the test ends, the conformance suite needs them.
+func stubSolver(t *testing.T, findZone func(fqdn string, nameservers []string) (string, error)) {
+	rateLimit, allowInline, findZoneFunc := *apiRateLimit, *allowInlineCredentials, findZoneByFqdn
+
+	t.Cleanup(func() {
+		*apiRateLimit, *allowInlineCredentials, findZoneByFqdn = rateLimit, allowInline, findZoneFunc
+	})
+
+	*apiRateLimit = 0
+	*allowInlineCredentials = true
+
+	if findZone != nil {
+		findZoneByFqdn = findZone
+	}
+}
+
+// staticZone returns a findZoneByFqdn resolving every name in zone
+func staticZone(zone string) func(fqdn string, nameservers []string) (string, error) {
+	return func(fqdn string, nameservers []string) (string, error) {
+		return zone, nil
+	}
+}
+
+// set seeds the record set name of zone
+func (f *fakeGoDaddy) set(zone, recordType, name string, records ...godaddy.DNSRecord) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.records[fmt.Sprintf("%s/%s/%s", zone, recordType, name)] = records
+}
+
+// methods returns the methods of the record calls since the last call
+func (f *fakeGoDaddy) methods() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	methods := make([]string, 0, len(f.calls))
+
+	for _, call := range f.calls {
+		method, _, _ := strings.Cut(call, " ")
+		methods = append(methods, method)
+	}
+
+	f.calls = nil
+
+	return methods
+}
+
+func (f *fakeGoDaddy) get(zone, recordType, name string) []godaddy.DNSRecord {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]godaddy.DNSRecord(nil), f.records[fmt.Sprintf("%s/%s/%s", zone, recordType, name)]...)
+}
+
+// owns reports whether the domain is served to the API key
+func (f *fakeGoDaddy) owns(domain, apiKey string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.owners == nil || f.owners[domain] == apiKey
+}
+
+func (f *fakeGoDaddy) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	credentials, found := strings.CutPrefix(r.Header.Get("Authorization"), "sso-key ")
+	if !found {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	apiKey, _, _ := strings.Cut(credentials, ":")
+
+	f.mu.Lock()
+	revoked := f.revoked[apiKey]
+	f.mu.Unlock()
+
+	if revoked {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"code":"UNABLE_TO_AUTHENTICATE","message":"Unauthorized : Could not authenticate API key/secret"}`))
+		return
+	}
+
+	if r.URL.Path == "/v1/domains" {
+		f.serveDomains(w, r)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/domains/"), "/")
+
+	// /v1/domains/{domain}
+	if len(parts) == 1 {
+		f.serveDomain(w, parts[0], apiKey)
+		return
+	}
+
+	// /v1/domains/{zone}/records/{type}/{name}
+	if len(parts) != 4 || parts[1] != "records" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if !f.owns(parts[0], apiKey) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"code":"ACCESS_DENIED","message":"Authenticated user is not allowed access"}`))
+		return
+	}
+
+	key := fmt.Sprintf("%s/%s/%s", parts[0], parts[2], parts[3])
+
+	f.mu.Lock()
+	f.calls = append(f.calls, r.Method+" "+key)
+	f.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	switch r.Method {
+	case http.MethodGet:
+		f.mu.Lock()
+		records := f.records[key]
+		f.mu.Unlock()
+
+		if records == nil {
+			records = []godaddy.DNSRecord{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(records)
+	case http.MethodPut:
+		var records []godaddy.DNSRecord
+
+		if err := json.NewDecoder(r.Body).Decode(&records); err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+
+		f.mu.Lock()
+		f.records[key] = records
+		f.mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		f.mu.Lock()
+		_, found := f.records[key]
+		delete(f.records, key)
+		lost := found && f.lostDeletes > 0
+		if lost {
+			f.lostDeletes--
+		}
+		f.mu.Unlock()
+
+		if lost {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeGoDaddy) serveDomains(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.domainsCalls++
+
+	domains := []godaddy.Domain{}
+
+	if r.URL.Query().Get("marker") == "" {
+		for _, domain := range f.domains {
+			domains = append(domains, godaddy.Domain{Domain: domain, Status: f.statusLocked(domain)})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(domains)
+}
+
+func (f *fakeGoDaddy) serveDomain(w http.ResponseWriter, domain, apiKey string) {
+	f.mu.Lock()
+	f.domainCalls++
+	f.mu.Unlock()
+
+	if !f.owns(domain, apiKey) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"code":"UNKNOWN_DOMAIN","message":"The given domain is not registered, or does not have a zone file"}`))
+		return
+	}
+
+	f.mu.Lock()
+	status := f.statusLocked(domain)
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(godaddy.Domain{Domain: domain, Status: status})
+}
+
+func (f *fakeGoDaddy) statusLocked(domain string) string {
+	if status, found := f.statuses[domain]; found {
+		return status
+	}
+
+	return "ACTIVE"
+}
+
+// fakeDNS is a DNS server answering from a static set of records
+type fakeDNS struct {
+	Addr string
+
+	mu      sync.Mutex
+	records map[string][]dns.RR
+	queries int
+}
+
+func newFakeDNS(t *testing.T) *fakeDNS {
+	f := &fakeDNS{
+		records: make(map[string][]dns.RR),
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	server := &dns.Server{
+		PacketConn:        conn,
+		Handler:           dns.HandlerFunc(f.serveDNS),
+		NotifyStartedFunc: func() { close(started) },
+	}
+
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+
+	<-started
+
+	t.Cleanup(func() {
+		_ = server.Shutdown()
+	})
+
+	f.Addr = conn.LocalAddr().String()
+
+	return f
+}
+
+// add registers records given in zone file format
+func (f *fakeDNS) add(t *testing.T, records ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, record := range records {
+		rr, err := dns.NewRR(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		key := fmt.Sprintf("%s/%d", strings.ToLower(rr.Header().Name), rr.Header().Rrtype)
+		f.records[key] = append(f.records[key], rr)
+	}
+}
+
+func (f *fakeDNS) serveDNS(w dns.ResponseWriter, r *dns.Msg) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.queries++
+
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+
+	for _, q := range r.Question {
+		m.Answer = append(m.Answer, f.records[fmt.Sprintf("%s/%d", strings.ToLower(q.Name), q.Qtype)]...)
+	}
+
+	_ = w.WriteMsg(m)
+}
+
+func newChallengeRequest(fqdn, zone, key, config string) *v1alpha1.ChallengeRequest {
+	return &v1alpha1.ChallengeRequest{
+		ResolvedFQDN:      fqdn,
+		ResolvedZone:      zone,
+		Key:               key,
+		ResourceNamespace: "default",
+		Config:            &extapi.JSON{Raw: []byte(config)},
+	}
+}
@@ -0,0 +1,420 @@
+// Package godaddy implements a client for the GoDaddy domains API.
+// See https://developer.godaddy.com/doc/endpoint/domains
+package godaddy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/time/rate"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// ProductionURL is the endpoint of the GoDaddy production environment
+	ProductionURL = "https://api.godaddy.com"
+	// OTEURL is the endpoint of the GoDaddy OTE (test) environment
+	OTEURL = "https://api.ote-godaddy.com"
+
+	// domainsPageSize is the maximum number of domains returned by a page of ListDomains
+	domainsPageSize = 1000
+	// recordsPageSize is the number of records fetched at once by ListRecords
+	recordsPageSize = 500
+)
+
+// DNSRecord a DNS record
+type DNSRecord struct {
+	Type     string  `json:"type"`
+	Name     string  `json:"name"`
+	Data     string  `json:"data"`
+	TTL      int     `json:"ttl,omitempty"`
+	Priority *int    `json:"priority,omitempty"`
+	Weight   *int    `json:"weight,omitempty"`
+	Protocol *string `json:"protocol,omitempty"`
+	Service  *string `json:"service,omitempty"`
+}
+
+// Domain a domain managed by the account
+type Domain struct {
+	DomainID    int64    `json:"domainId"`
+	Domain      string   `json:"domain"`
+	Status      string   `json:"status"`
+	NameServers []string `json:"nameServers,omitempty"`
+}
+
+// Client a GoDaddy API client bound to one account.
+// A Client is safe for concurrent use.
+type Client struct {
+	baseURL     string
+	apiKey      string
+	apiSecret   string
+	shopperID   string
+	userAgent   string
+	httpClient  *http.Client
+	retryPolicy RetryPolicy
+	rateLimit   RateLimit
+	limiter     *rate.Limiter
+}
+
+// Option customizes a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the http.Client used to send the requests, see NewHTTPClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithShopperID sends the requests on behalf of the reseller's sub-account shopperID
+func WithShopperID(shopperID string) Option {
+	return func(c *Client) {
+		c.shopperID = shopperID
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with the requests
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// NewClient returns a client for the account identified by apiKey and apiSecret.
+// baseURL is the API endpoint, e.g. ProductionURL or OTEURL.
+func NewClient(baseURL, apiKey, apiSecret string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		apiKey:      apiKey,
+		apiSecret:   apiSecret,
+		userAgent:   "godaddy-webhook",
+		httpClient:  defaultHTTPClient,
+		retryPolicy: DefaultRetryPolicy,
+		rateLimit:   DefaultRateLimit,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.rateLimit.RequestsPerMinute > 0 {
+		c.limiter = sharedLimiter(c.apiKeyID(), c.rateLimit)
+	}
+
+	return c
+}
+
+// BaseURL returns the API endpoint used by the client
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// ShopperID returns the reseller's sub-account used by the client, empty if none
+func (c *Client) ShopperID() string {
+	return c.shopperID
+}
+
+// Account returns a stable identifier of the account which doesn't disclose the credentials
+func (c *Client) Account() string {
+	if c.shopperID != "" {
+		return fmt.Sprintf("%s/%s", c.apiKeyID(), c.shopperID)
+	}
+
+	return c.apiKeyID()
+}
+
+func (c *Client) apiKeyID() string {
+	return Fingerprint(c.apiKey)
+}
+
+// Fingerprint returns a short hash of a credential, it tells the credentials apart
+// in the logs without disclosing them
+func Fingerprint(credential string) string {
+	sum := sha256.Sum256([]byte(credential))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// ListRecords returns every record of the domain, the pages are fetched until the last one.
+// Prefer GetRecords or WalkRecords on large zones.
+func (c *Client) ListRecords(ctx context.Context, domain string) ([]DNSRecord, error) {
+	var records []DNSRecord
+
+	err := c.WalkRecords(ctx, domain, recordsPageSize, func(record DNSRecord) error {
+		records = append(records, record)
+
+		return nil
+	})
+
+	return records, err
+}
+
+// ListRecordsPage returns at most limit records of the domain, skipping the first offset ones
+func (c *Client) ListRecordsPage(ctx context.Context, domain string, offset, limit int) ([]DNSRecord, error) {
+	var records []DNSRecord
+
+	query := url.Values{}
+	query.Set("offset", strconv.Itoa(offset))
+	query.Set("limit", strconv.Itoa(limit))
+
+	// The records are decoded one by one from the body, the slice is reset
+	// in case the request is sent again after a partial read.
+	decode := decodeFunc(func(decoder *json.Decoder) error {
+		records = records[:0]
+
+		return decodeArray(decoder, func(decoder *json.Decoder) error {
+			var record DNSRecord
+
+			if err := decoder.Decode(&record); err != nil {
+				return err
+			}
+
+			records = append(records, record)
+
+			return nil
+		})
+	})
+
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/v1/domains/%s/records?%s", domain, query.Encode()), nil, http.StatusOK, decode); err != nil {
+		return nil, fmt.Errorf("unable to list records for zone: %s; %w", domain, err)
+	}
+
+	return records, nil
+}
+
+// WalkRecords calls fn for every record of the domain, fetching pageSize records at once.
+// The walk stops at the first error returned by fn.
+func (c *Client) WalkRecords(ctx context.Context, domain string, pageSize int, fn func(DNSRecord) error) error {
+	if pageSize <= 0 {
+		pageSize = recordsPageSize
+	}
+
+	for offset := 0; ; offset += pageSize {
+		page, err := c.ListRecordsPage(ctx, domain, offset, pageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range page {
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+
+		if len(page) < pageSize {
+			return nil
+		}
+	}
+}
+
+// GetRecords returns the values of the record set recordType/name of the domain
+func (c *Client) GetRecords(ctx context.Context, domain, recordType, name string) ([]DNSRecord, error) {
+	var records []DNSRecord
+
+	if err := c.do(ctx, http.MethodGet, recordsPath(domain, recordType, name), nil, http.StatusOK, &records); err != nil {
+		return nil, fmt.Errorf("unable to get records %s/%s for zone: %s; %w", recordType, name, domain, err)
+	}
+
+	return records, nil
+}
+
+// ReplaceRecords overwrites every value of the record set recordType/name of the domain
+func (c *Client) ReplaceRecords(ctx context.Context, domain, recordType, name string, records []DNSRecord) error {
+	if err := c.do(ctx, http.MethodPut, recordsPath(domain, recordType, name), records, http.StatusOK, nil); err != nil {
+		return fmt.Errorf("unable to replace records %s/%s for zone: %s; %w", recordType, name, domain, err)
+	}
+
+	return nil
+}
+
+// PatchRecords adds the records to the domain
+func (c *Client) PatchRecords(ctx context.Context, domain string, records []DNSRecord) error {
+	if err := c.do(ctx, http.MethodPatch, fmt.Sprintf("/v1/domains/%s/records", domain), records, http.StatusOK, nil); err != nil {
+		return fmt.Errorf("unable to add records for zone: %s; %w", domain, err)
+	}
+
+	return nil
+}
+
+// DeleteRecords removes every value of the record set recordType/name of the domain
+func (c *Client) DeleteRecords(ctx context.Context, domain, recordType, name string) error {
+	if err := c.do(ctx, http.MethodDelete, recordsPath(domain, recordType, name), nil, http.StatusNoContent, nil); err != nil {
+		return fmt.Errorf("unable to delete records %s/%s for zone: %s; %w", recordType, name, domain, err)
+	}
+
+	return nil
+}
+
+// ListDomains returns the domains of the account, the pages are fetched until the last one
+func (c *Client) ListDomains(ctx context.Context) ([]Domain, error) {
+	var domains []Domain
+
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(domainsPageSize))
+
+	for {
+		var page []Domain
+
+		if err := c.do(ctx, http.MethodGet, "/v1/domains?"+query.Encode(), nil, http.StatusOK, &page); err != nil {
+			return nil, fmt.Errorf("unable to list domains; %w", err)
+		}
+
+		domains = append(domains, page...)
+
+		if len(page) < domainsPageSize {
+			return domains, nil
+		}
+
+		query.Set("marker", page[len(page)-1].Domain)
+	}
+}
+
+// GetDomain returns the details of the domain
+func (c *Client) GetDomain(ctx context.Context, domain string) (*Domain, error) {
+	var result Domain
+
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/v1/domains/%s", domain), nil, http.StatusOK, &result); err != nil {
+		return nil, fmt.Errorf("unable to get domain: %s; %w", domain, err)
+	}
+
+	return &result, nil
+}
+
+// decodeFunc reads a successful response directly from the body
+type decodeFunc func(*json.Decoder) error
+
+// decodeArray calls each for every element of the JSON array read by decoder
+func decodeArray(decoder *json.Decoder, each func(*json.Decoder) error) error {
+	if token, err := decoder.Token(); err != nil {
+		return err
+	} else if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected an array, got: %v", token)
+	}
+
+	for decoder.More() {
+		if err := each(decoder); err != nil {
+			return err
+		}
+	}
+
+	_, err := decoder.Token()
+
+	return err
+}
+
+func recordsPath(domain, recordType, name string) string {
+	return fmt.Sprintf("/v1/domains/%s/records/%s/%s", domain, recordType, url.PathEscape(name))
+}
+
+// do sends the request and decodes the response into out when not nil,
+// out is either a decodeFunc or a value given to json.Decoder.Decode.
+// A response with another status than expected is returned as an *APIError.
+// Idempotent requests failing with a retryable error are sent again according to
+// the retry policy, as long as the deadline of ctx allows it.
+func (c *Client) do(ctx context.Context, method, uri string, in interface{}, expected int, out interface{}) error {
+	var payload []byte
+
+	if in != nil {
+		var err error
+
+		if payload, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	maxRetries := 0
+
+	if isIdempotent(method) {
+		maxRetries = c.retryPolicy.MaxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := c.send(ctx, method, uri, payload, expected, out)
+
+		if err == nil || attempt >= maxRetries || !IsRetryable(err) {
+			return err
+		}
+
+		delay := c.retryPolicy.backoff(attempt, err)
+
+		klog.V(2).Infof("Retry %s %s in %s, attempt %d/%d, shopper: %s, error: %v", method, uri, delay, attempt+1, maxRetries, c.shopperID, err)
+
+		if !wait(ctx, delay) {
+			return err
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, uri string, payload []byte, expected int, out interface{}) error {
+	var body io.Reader
+
+	if err := c.throttle(ctx); err != nil {
+		return err
+	}
+
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+uri, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("sso-key %s:%s", c.apiKey, c.apiSecret))
+
+	if c.shopperID != "" {
+		req.Header.Set("X-Shopper-Id", c.shopperID)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		apiRequestsTotal.WithLabelValues(method, "error", c.shopperID).Inc()
+
+		return err
+	}
+
+	apiRequestsTotal.WithLabelValues(method, strconv.Itoa(resp.StatusCode), c.shopperID).Inc()
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expected {
+		bodyBytes, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+
+		return newAPIError(resp.StatusCode, resp.Header, bodyBytes)
+	}
+
+	if out != nil {
+		decoder := json.NewDecoder(resp.Body)
+
+		if decode, ok := out.(decodeFunc); ok {
+			err = decode(decoder)
+		} else {
+			err = decoder.Decode(out)
+		}
+
+		if err != nil {
+			return fmt.Errorf("unable to decode response: %w", err)
+		}
+	}
+
+	// Drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
@@ -0,0 +1,332 @@
+package godaddy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+type recordedRequest struct {
+	method string
+	path   string
+	body   string
+}
+
+func newTestClient(t *testing.T, status int, response string) (*Client, *recordedRequest) {
+	recorded := &recordedRequest{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "sso-key key:secret" {
+			t.Errorf("unexpected Authorization header: %s", got)
+		}
+
+		body, _ := io.ReadAll(r.Body)
+
+		recorded.method = r.Method
+		recorded.path = r.URL.RequestURI()
+		recorded.body = string(body)
+
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, response)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return NewClient(server.URL, "key", "secret", WithRetryPolicy(RetryPolicy{}), WithRateLimit(RateLimit{})), recorded
+}
+
+func TestGetRecords(t *testing.T) {
+	client, recorded := newTestClient(t, http.StatusOK, `[{"type":"TXT","name":"_acme-challenge","data":"a","ttl":600}]`)
+
+	records, err := client.GetRecords(context.Background(), "example.com", "TXT", "_acme-challenge")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []DNSRecord{{Type: "TXT", Name: "_acme-challenge", Data: "a", TTL: 600}}
+
+	if !reflect.DeepEqual(records, expected) {
+		t.Fatalf("unexpected records: %v", records)
+	}
+
+	if recorded.method != http.MethodGet || recorded.path != "/v1/domains/example.com/records/TXT/_acme-challenge" {
+		t.Fatalf("unexpected request: %s %s", recorded.method, recorded.path)
+	}
+}
+
+func TestReplaceRecords(t *testing.T) {
+	client, recorded := newTestClient(t, http.StatusOK, "")
+	records := []DNSRecord{{Type: "TXT", Name: "_acme-challenge", Data: "a", TTL: 600}, {Type: "TXT", Name: "_acme-challenge", Data: "b"}}
+
+	if err := client.ReplaceRecords(context.Background(), "example.com", "TXT", "_acme-challenge", records); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent []DNSRecord
+
+	if err := json.Unmarshal([]byte(recorded.body), &sent); err != nil {
+		t.Fatal(err)
+	}
+
+	if recorded.method != http.MethodPut || recorded.path != "/v1/domains/example.com/records/TXT/_acme-challenge" || !reflect.DeepEqual(sent, records) {
+		t.Fatalf("unexpected request: %s %s %s", recorded.method, recorded.path, recorded.body)
+	}
+}
+
+func TestPatchRecords(t *testing.T) {
+	client, recorded := newTestClient(t, http.StatusOK, "")
+
+	if err := client.PatchRecords(context.Background(), "example.com", []DNSRecord{{Type: "TXT", Name: "www", Data: "a"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if recorded.method != http.MethodPatch || recorded.path != "/v1/domains/example.com/records" {
+		t.Fatalf("unexpected request: %s %s", recorded.method, recorded.path)
+	}
+}
+
+func TestDeleteRecords(t *testing.T) {
+	client, recorded := newTestClient(t, http.StatusNoContent, "")
+
+	if err := client.DeleteRecords(context.Background(), "example.com", "TXT", "_acme-challenge"); err != nil {
+		t.Fatal(err)
+	}
+
+	if recorded.method != http.MethodDelete || recorded.path != "/v1/domains/example.com/records/TXT/_acme-challenge" {
+		t.Fatalf("unexpected request: %s %s", recorded.method, recorded.path)
+	}
+}
+
+func TestListDomains(t *testing.T) {
+	client, recorded := newTestClient(t, http.StatusOK, `[{"domainId":1,"domain":"example.com","status":"ACTIVE"}]`)
+
+	domains, err := client.ListDomains(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(domains) != 1 || domains[0].Domain != "example.com" || recorded.path != "/v1/domains?limit=1000" {
+		t.Fatalf("unexpected domains: %v from %s", domains, recorded.path)
+	}
+}
+
+func TestGetDomain(t *testing.T) {
+	client, recorded := newTestClient(t, http.StatusOK, `{"domainId":1,"domain":"example.com","status":"ACTIVE","nameServers":["ns1.domaincontrol.com"]}`)
+
+	domain, err := client.GetDomain(context.Background(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if domain.Domain != "example.com" || len(domain.NameServers) != 1 || recorded.path != "/v1/domains/example.com" {
+		t.Fatalf("unexpected domain: %v from %s", domain, recorded.path)
+	}
+}
+
+func TestUnexpectedStatus(t *testing.T) {
+	client, _ := newTestClient(t, http.StatusNotFound, `{"code":"NOT_FOUND","message":"Domain not found"}`)
+
+	if _, err := client.GetRecords(context.Background(), "example.com", "TXT", "_acme-challenge"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	client, _ := newTestClient(t, http.StatusUnprocessableEntity, `{"code":"INVALID_BODY","message":"Request body doesn't fulfill schema","fields":[{"code":"UNEXPECTED_TYPE","path":"records[0].ttl","message":"is not a integer"}]}`)
+
+	err := client.ReplaceRecords(context.Background(), "example.com", "TXT", "_acme-challenge", nil)
+
+	apiErr, ok := asAPIError(err)
+	if !ok {
+		t.Fatalf("expected an APIError, got: %v", err)
+	}
+
+	if apiErr.StatusCode != http.StatusUnprocessableEntity || apiErr.Code != "INVALID_BODY" || len(apiErr.Fields) != 1 || apiErr.Fields[0].Path != "records[0].ttl" {
+		t.Fatalf("unexpected error: %#v", apiErr)
+	}
+
+	if !IsValidationError(err) || IsRetryable(err) {
+		t.Fatalf("unexpected classification of: %v", err)
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		status    int
+		body      string
+		check     func(error) bool
+		retryable bool
+	}{
+		{http.StatusUnauthorized, `{"code":"UNABLE_TO_AUTHENTICATE","message":"Unauthorized : Could not authenticate API key/secret"}`, IsUnauthorized, false},
+		{http.StatusForbidden, `{"code":"ACCESS_DENIED","message":"Authenticated user is not allowed access"}`, IsAccessDenied, false},
+		{http.StatusNotFound, `{"code":"UNKNOWN_DOMAIN","message":"The given domain is not registered"}`, IsNotFound, false},
+		{http.StatusTooManyRequests, `{"code":"TOO_MANY_REQUESTS","message":"Too many requests received within interval","retryAfterSec":30}`, IsRateLimited, true},
+		{http.StatusBadGateway, `<html>Bad Gateway</html>`, IsRetryable, true},
+	}
+
+	for _, test := range tests {
+		client, _ := newTestClient(t, test.status, test.body)
+
+		_, err := client.GetDomain(context.Background(), "example.com")
+
+		if !test.check(err) {
+			t.Errorf("unexpected classification of: %v", err)
+		}
+
+		if IsRetryable(err) != test.retryable {
+			t.Errorf("expected retryable: %v for: %v", test.retryable, err)
+		}
+	}
+}
+
+func TestSharedHTTPClientReusesConnections(t *testing.T) {
+	var connections int32
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[]`)
+	}))
+
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+
+	server.Start()
+	t.Cleanup(server.Close)
+
+	httpClient := NewHTTPClient(DefaultTransportConfig)
+
+	for _, apiKey := range []string{"key-1", "key-2", "key-1"} {
+		client := NewClient(server.URL, apiKey, "secret", WithHTTPClient(httpClient), WithRateLimit(RateLimit{}))
+
+		if _, err := client.ListDomains(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if connections != 1 {
+		t.Fatalf("expected 1 connection, got: %d", connections)
+	}
+}
+
+func TestShopperID(t *testing.T) {
+	var shopperID string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		shopperID = r.Header.Get("X-Shopper-Id")
+		_, _ = io.WriteString(w, `[]`)
+	}))
+
+	t.Cleanup(server.Close)
+
+	reseller := NewClient(server.URL, "key", "secret", WithRateLimit(RateLimit{}))
+	customer := NewClient(server.URL, "key", "secret", WithShopperID("123456"), WithRateLimit(RateLimit{}))
+
+	if _, err := customer.ListDomains(context.Background()); err != nil || shopperID != "123456" {
+		t.Fatalf("expected X-Shopper-Id: 123456, got: %q, error: %v", shopperID, err)
+	}
+
+	if _, err := reseller.ListDomains(context.Background()); err != nil || shopperID != "" {
+		t.Fatalf("expected no X-Shopper-Id, got: %q, error: %v", shopperID, err)
+	}
+
+	if reseller.Account() == customer.Account() {
+		t.Fatal("expected distinct accounts for distinct shoppers")
+	}
+}
+
+func TestListDomainsPages(t *testing.T) {
+	var markers []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		marker := r.URL.Query().Get("marker")
+		markers = append(markers, marker)
+
+		page := []Domain{}
+
+		if marker == "" {
+			for i := 0; i < domainsPageSize; i++ {
+				page = append(page, Domain{Domain: fmt.Sprintf("domain-%04d.com", i)})
+			}
+		} else {
+			page = append(page, Domain{Domain: "last.com"})
+		}
+
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+
+	t.Cleanup(server.Close)
+
+	domains, err := NewClient(server.URL, "key", "secret", WithRateLimit(RateLimit{})).ListDomains(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(domains) != domainsPageSize+1 || !reflect.DeepEqual(markers, []string{"", fmt.Sprintf("domain-%04d.com", domainsPageSize-1)}) {
+		t.Fatalf("unexpected pages: %d domains with markers %v", len(domains), markers)
+	}
+}
+
+func TestListRecordsPages(t *testing.T) {
+	var offsets []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset := r.URL.Query().Get("offset")
+		offsets = append(offsets, offset)
+
+		if limit := r.URL.Query().Get("limit"); limit != "2" {
+			t.Errorf("unexpected limit: %s", limit)
+		}
+
+		page := []DNSRecord{}
+
+		switch offset {
+		case "0":
+			page = append(page, DNSRecord{Type: "A", Name: "@", Data: "1.2.3.4"}, DNSRecord{Type: "A", Name: "www", Data: "1.2.3.4"})
+		case "2":
+			page = append(page, DNSRecord{Type: "TXT", Name: "_acme-challenge", Data: "a"})
+		}
+
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+
+	t.Cleanup(server.Close)
+
+	client := NewClient(server.URL, "key", "secret", WithRateLimit(RateLimit{}))
+
+	var names []string
+
+	err := client.WalkRecords(context.Background(), "example.com", 2, func(record DNSRecord) error {
+		names = append(names, record.Name)
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(names, []string{"@", "www", "_acme-challenge"}) || !reflect.DeepEqual(offsets, []string{"0", "2"}) {
+		t.Fatalf("unexpected walk: %v with offsets %v", names, offsets)
+	}
+}
+
+func TestListRecordsPageMalformed(t *testing.T) {
+	client, recorded := newTestClient(t, http.StatusOK, `{"type":"TXT"}`)
+
+	if _, err := client.ListRecordsPage(context.Background(), "example.com", 10, 5); err == nil {
+		t.Fatal("expected an error on a response which is not an array")
+	}
+
+	if recorded.path != "/v1/domains/example.com/records?limit=5&offset=10" {
+		t.Fatalf("unexpected request: %s", recorded.path)
+	}
+}
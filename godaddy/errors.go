@@ -0,0 +1,166 @@
+package godaddy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Error codes returned by the GoDaddy API
+const (
+	CodeUnableToAuthenticate = "UNABLE_TO_AUTHENTICATE"
+	CodeAccessDenied         = "ACCESS_DENIED"
+	CodeNotFound             = "NOT_FOUND"
+	CodeUnknownDomain        = "UNKNOWN_DOMAIN"
+	CodeTooManyRequests      = "TOO_MANY_REQUESTS"
+)
+
+// maxErrorBodyLength bounds the size of a non JSON error body kept in an APIError
+const maxErrorBodyLength = 512
+
+// ErrorField describes an invalid field of a request
+type ErrorField struct {
+	Code        string `json:"code"`
+	Message     string `json:"message,omitempty"`
+	Path        string `json:"path"`
+	PathRelated string `json:"pathRelated,omitempty"`
+}
+
+// APIError is the error returned by the GoDaddy API for a non-2xx response
+type APIError struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int `json:"-"`
+	// Code is the GoDaddy error code, e.g. UNABLE_TO_AUTHENTICATE
+	Code    string       `json:"code"`
+	Message string       `json:"message,omitempty"`
+	Fields  []ErrorField `json:"fields,omitempty"`
+	// RetryAfterSec is the delay asked by a throttled response body
+	RetryAfterSec int `json:"retryAfterSec,omitempty"`
+	// RetryAfter is the delay asked by the server before sending the request again,
+	// from the Retry-After header or the retryAfterSec field.
+	RetryAfter time.Duration `json:"-"`
+}
+
+func newAPIError(statusCode int, header http.Header, body []byte) *APIError {
+	apiErr := &APIError{}
+
+	if err := json.Unmarshal(body, apiErr); err != nil || apiErr.Code == "" {
+		message := strings.TrimSpace(string(body))
+
+		if len(message) > maxErrorBodyLength {
+			message = message[:maxErrorBodyLength] + "..."
+		}
+
+		apiErr = &APIError{
+			Message: message,
+		}
+	}
+
+	apiErr.StatusCode = statusCode
+	apiErr.RetryAfter = parseRetryAfter(header.Get("Retry-After"))
+
+	if apiErr.RetryAfter == 0 && apiErr.RetryAfterSec > 0 {
+		apiErr.RetryAfter = time.Duration(apiErr.RetryAfterSec) * time.Second
+	}
+
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "godaddy api error, status: %d", e.StatusCode)
+
+	if e.Code != "" {
+		fmt.Fprintf(&sb, ", code: %s", e.Code)
+	}
+
+	if e.Message != "" {
+		fmt.Fprintf(&sb, ", message: %s", e.Message)
+	}
+
+	if e.RetryAfter > 0 {
+		fmt.Fprintf(&sb, ", retry after: %s", e.RetryAfter)
+	}
+
+	for _, field := range e.Fields {
+		fmt.Fprintf(&sb, ", field %s: %s", field.Path, field.Code)
+
+		if field.Message != "" {
+			fmt.Fprintf(&sb, " (%s)", field.Message)
+		}
+	}
+
+	return sb.String()
+}
+
+func asAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+
+	return nil, false
+}
+
+// IsUnauthorized returns true if the credentials have been rejected
+func IsUnauthorized(err error) bool {
+	apiErr, ok := asAPIError(err)
+
+	return ok && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.Code == CodeUnableToAuthenticate)
+}
+
+// IsAccessDenied returns true if the account is not allowed to perform the request
+func IsAccessDenied(err error) bool {
+	apiErr, ok := asAPIError(err)
+
+	return ok && (apiErr.StatusCode == http.StatusForbidden || apiErr.Code == CodeAccessDenied)
+}
+
+// IsNotFound returns true if the domain or the resource doesn't exist
+func IsNotFound(err error) bool {
+	apiErr, ok := asAPIError(err)
+
+	return ok && (apiErr.StatusCode == http.StatusNotFound || apiErr.Code == CodeNotFound || apiErr.Code == CodeUnknownDomain)
+}
+
+// IsValidationError returns true if the request has been rejected as malformed
+func IsValidationError(err error) bool {
+	apiErr, ok := asAPIError(err)
+
+	return ok && (apiErr.StatusCode == http.StatusBadRequest || apiErr.StatusCode == http.StatusUnprocessableEntity)
+}
+
+// IsRateLimited returns true if the request has been throttled
+func IsRateLimited(err error) bool {
+	apiErr, ok := asAPIError(err)
+
+	return ok && (apiErr.StatusCode == http.StatusTooManyRequests || apiErr.Code == CodeTooManyRequests)
+}
+
+// IsRetryable returns true if the request may succeed when sent again:
+// throttled requests, server side failures and network errors.
+func IsRetryable(err error) bool {
+	if apiErr, ok := asAPIError(err); ok {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+
+		return apiErr.Code == CodeTooManyRequests
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,46 @@
+package godaddy
+
+import (
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const metricsNamespace = "godaddy_webhook"
+
+var (
+	apiRequestsTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      "api",
+			Name:           "requests_total",
+			Help:           "Number of GoDaddy API requests by method, status code and shopper id.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"method", "code", "shopper_id"},
+	)
+
+	rateLimiterQueueDepth = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      "api",
+			Name:           "rate_limiter_queue_depth",
+			Help:           "Number of GoDaddy API requests waiting for the client side rate limiter.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	rateLimiterWaitDuration = metrics.NewHistogram(
+		&metrics.HistogramOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      "api",
+			Name:           "rate_limiter_wait_duration_seconds",
+			Help:           "Time spent by a GoDaddy API request waiting for the client side rate limiter.",
+			Buckets:        []float64{0.001, 0.01, 0.1, 0.5, 1, 5, 15, 30, 60, 120},
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+)
+
+func init() {
+	legacyregistry.MustRegister(apiRequestsTotal, rateLimiterQueueDepth, rateLimiterWaitDuration)
+}
@@ -0,0 +1,71 @@
+package godaddy
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimit configures the client side throttling of the requests.
+// GoDaddy allows 60 requests per minute for each API key.
+type RateLimit struct {
+	// RequestsPerMinute is the sustained rate, 0 disables the throttling
+	RequestsPerMinute float64
+	// Burst is the number of requests which can be sent at once
+	Burst int
+}
+
+// DefaultRateLimit matches the quota of the GoDaddy API
+var DefaultRateLimit = RateLimit{
+	RequestsPerMinute: 60,
+	Burst:             5,
+}
+
+// limiters holds the limiter of each API key, they're shared by every client of the process
+var limiters sync.Map
+
+// WithRateLimit throttles the requests sent with the API key of the client, DefaultRateLimit if not set.
+// The limiter is shared by all the clients using the same API key, it keeps the limit
+// of the first client created with that key.
+func WithRateLimit(limit RateLimit) Option {
+	return func(c *Client) {
+		c.rateLimit = limit
+	}
+}
+
+// sharedLimiter returns the limiter of the API key, created with limit unless it exists.
+// The settings of an existing limiter are never changed, they're shared by other clients.
+func sharedLimiter(account string, limit RateLimit) *rate.Limiter {
+	if value, found := limiters.Load(account); found {
+		return value.(*rate.Limiter)
+	}
+
+	burst := limit.Burst
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	value, _ := limiters.LoadOrStore(account, rate.NewLimiter(rate.Limit(limit.RequestsPerMinute/60), burst))
+
+	return value.(*rate.Limiter)
+}
+
+// throttle waits until the request is allowed by the rate limiter or ctx is done
+func (c *Client) throttle(ctx context.Context) error {
+	if c.limiter == nil {
+		return nil
+	}
+
+	rateLimiterQueueDepth.Inc()
+	defer rateLimiterQueueDepth.Dec()
+
+	start := time.Now()
+	err := c.limiter.Wait(ctx)
+
+	rateLimiterWaitDuration.Observe(time.Since(start).Seconds())
+
+	return err
+}
@@ -0,0 +1,79 @@
+package godaddy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitSharedByAPIKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[]`)
+	}))
+
+	t.Cleanup(server.Close)
+
+	// 10 requests per second, the first one is allowed at once
+	limit := RateLimit{RequestsPerMinute: 600, Burst: 1}
+	first := NewClient(server.URL, "rate-limited-key", "secret", WithRateLimit(limit))
+	second := NewClient(server.URL, "rate-limited-key", "secret", WithRateLimit(limit))
+	other := NewClient(server.URL, "other-key", "secret", WithRateLimit(limit))
+
+	start := time.Now()
+
+	for _, client := range []*Client{first, second, first, other} {
+		if _, err := client.ListDomains(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("expected 3 requests throttled at 10/s, elapsed: %s", elapsed)
+	}
+}
+
+func TestRateLimitRespectsContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[]`)
+	}))
+
+	t.Cleanup(server.Close)
+
+	client := NewClient(server.URL, "slow-key", "secret", WithRateLimit(RateLimit{RequestsPerMinute: 1, Burst: 1}))
+
+	if _, err := client.ListDomains(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.ListDomains(ctx); err == nil {
+		t.Fatal("expected the rate limiter to give up before the deadline")
+	}
+}
+
+func TestRateLimitKeptByLaterClients(t *testing.T) {
+	limit := RateLimit{RequestsPerMinute: 600, Burst: 2}
+	first := NewClient("http://localhost", "kept-key", "secret", WithRateLimit(limit))
+
+	for _, client := range []*Client{
+		NewClient("http://localhost", "kept-key", "secret"),
+		NewClient("http://localhost", "kept-key", "secret", WithRateLimit(RateLimit{RequestsPerMinute: 6, Burst: 1})),
+	} {
+		if client.limiter != first.limiter {
+			t.Fatal("expected the limiter to be shared by the clients of the API key")
+		}
+	}
+
+	if first.limiter.Limit() != 10 || first.limiter.Burst() != 2 {
+		t.Fatalf("expected the limiter to keep 10/s with a burst of 2, got: %v/s with a burst of %d", first.limiter.Limit(), first.limiter.Burst())
+	}
+
+	if NewClient("http://localhost", "kept-key", "secret", WithRateLimit(RateLimit{})).limiter != nil {
+		t.Fatal("expected no limiter when the throttling is disabled")
+	}
+}
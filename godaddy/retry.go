@@ -0,0 +1,108 @@
+package godaddy
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how the idempotent requests (GET, PUT, DELETE) are retried
+// when they fail with a retryable error. See IsRetryable.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries, 0 disables the retries
+	MaxRetries int
+	// MinBackoff is the delay before the first retry, doubled on each retry
+	MinBackoff time.Duration
+	// MaxBackoff bounds the delay between two retries
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy used when none is specified
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 5,
+	MinBackoff: 500 * time.Millisecond,
+	MaxBackoff: 30 * time.Second,
+}
+
+// WithRetryPolicy sets the retry policy of the client
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// backoff returns the delay before the retry number attempt (starting at 0).
+// The delay asked by the server is honored, otherwise it's an exponential
+// backoff with jitter.
+func (p RetryPolicy) backoff(attempt int, err error) time.Duration {
+	if apiErr, ok := asAPIError(err); ok && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter
+	}
+
+	delay := p.MaxBackoff
+
+	if attempt < 32 {
+		if exp := p.MinBackoff << uint(attempt); exp > 0 && exp < p.MaxBackoff {
+			delay = exp
+		}
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	// Equal jitter, the delay is between delay/2 and delay
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// wait sleeps for delay unless ctx is done or its deadline is reached before the
+// end of the delay, it returns false in this case.
+func wait(ctx context.Context, delay time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return false
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// parseRetryAfter decodes a Retry-After header, in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+
+		return 0
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
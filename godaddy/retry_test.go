@@ -0,0 +1,146 @@
+package godaddy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	MinBackoff: time.Millisecond,
+	MaxBackoff: 10 * time.Millisecond,
+}
+
+// newFlakyClient returns a client on a server failing with the given responses
+// before succeeding.
+func newFlakyClient(t *testing.T, failures []func(http.ResponseWriter)) (*Client, *int32) {
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(atomic.AddInt32(&calls, 1)) - 1
+
+		if call < len(failures) {
+			failures[call](w)
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		_, _ = io.WriteString(w, `[]`)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return NewClient(server.URL, "key", "secret", WithRetryPolicy(testRetryPolicy), WithRateLimit(RateLimit{})), &calls
+}
+
+func failWith(status int, header, body string) func(http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		if header != "" {
+			w.Header().Set("Retry-After", header)
+		}
+
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}
+}
+
+func TestRetryTransientErrors(t *testing.T) {
+	client, calls := newFlakyClient(t, []func(http.ResponseWriter){
+		failWith(http.StatusServiceUnavailable, "", ""),
+		failWith(http.StatusInternalServerError, "", `{"code":"INTERNAL_SERVER_ERROR"}`),
+	})
+
+	if err := client.DeleteRecords(context.Background(), "example.com", "TXT", "_acme-challenge"); err != nil {
+		t.Fatal(err)
+	}
+
+	if *calls != 3 {
+		t.Fatalf("expected 3 calls, got: %d", *calls)
+	}
+}
+
+func TestRetryGiveUp(t *testing.T) {
+	failure := failWith(http.StatusServiceUnavailable, "", "")
+	client, calls := newFlakyClient(t, []func(http.ResponseWriter){failure, failure, failure, failure, failure})
+
+	if _, err := client.GetRecords(context.Background(), "example.com", "TXT", "_acme-challenge"); !IsRetryable(err) {
+		t.Fatalf("expected a retryable error, got: %v", err)
+	}
+
+	if *calls != int32(testRetryPolicy.MaxRetries+1) {
+		t.Fatalf("expected %d calls, got: %d", testRetryPolicy.MaxRetries+1, *calls)
+	}
+}
+
+func TestNoRetryOnPermanentError(t *testing.T) {
+	client, calls := newFlakyClient(t, []func(http.ResponseWriter){
+		failWith(http.StatusUnauthorized, "", `{"code":"UNABLE_TO_AUTHENTICATE"}`),
+	})
+
+	if _, err := client.GetRecords(context.Background(), "example.com", "TXT", "_acme-challenge"); !IsUnauthorized(err) {
+		t.Fatalf("expected unauthorized, got: %v", err)
+	}
+
+	if *calls != 1 {
+		t.Fatalf("expected 1 call, got: %d", *calls)
+	}
+}
+
+func TestNoRetryOnPatch(t *testing.T) {
+	client, calls := newFlakyClient(t, []func(http.ResponseWriter){
+		failWith(http.StatusServiceUnavailable, "", ""),
+	})
+
+	if err := client.PatchRecords(context.Background(), "example.com", nil); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if *calls != 1 {
+		t.Fatalf("expected 1 call, got: %d", *calls)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	client, _ := newFlakyClient(t, []func(http.ResponseWriter){
+		failWith(http.StatusTooManyRequests, "1", `{"code":"TOO_MANY_REQUESTS"}`),
+		failWith(http.StatusTooManyRequests, "", `{"code":"TOO_MANY_REQUESTS","retryAfterSec":1}`),
+	})
+
+	start := time.Now()
+
+	if _, err := client.GetRecords(context.Background(), "example.com", "TXT", "_acme-challenge"); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 2*time.Second {
+		t.Fatalf("Retry-After not honored, elapsed: %s", elapsed)
+	}
+}
+
+func TestRetryBoundedByDeadline(t *testing.T) {
+	client, calls := newFlakyClient(t, []func(http.ResponseWriter){
+		failWith(http.StatusTooManyRequests, "60", `{"code":"TOO_MANY_REQUESTS"}`),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+
+	if _, err := client.GetRecords(ctx, "example.com", "TXT", "_acme-challenge"); !IsRateLimited(err) {
+		t.Fatalf("expected rate limited, got: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond || *calls != 1 {
+		t.Fatalf("retry must be abandoned when the deadline is too close, elapsed: %s, calls: %d", elapsed, *calls)
+	}
+}
@@ -0,0 +1,73 @@
+package godaddy
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportConfig tunes the HTTP client used to reach the GoDaddy API
+type TransportConfig struct {
+	// Timeout bounds a whole request, response body included
+	Timeout time.Duration
+	// DialTimeout bounds the establishment of a TCP connection
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout bounds the TLS handshake
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout bounds the wait of the response headers once the request is sent
+	ResponseHeaderTimeout time.Duration
+	// IdleConnTimeout is the time an idle connection is kept in the pool
+	IdleConnTimeout time.Duration
+	// MaxIdleConns is the size of the idle connection pool
+	MaxIdleConns int
+	// MaxIdleConnsPerHost is the size of the idle connection pool for each host
+	MaxIdleConnsPerHost int
+	// DisableHTTP2 forces the use of HTTP/1.1
+	DisableHTTP2 bool
+}
+
+// DefaultTransportConfig is the configuration of the HTTP client used when none is specified
+var DefaultTransportConfig = TransportConfig{
+	Timeout:               30 * time.Second,
+	DialTimeout:           10 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ResponseHeaderTimeout: 20 * time.Second,
+	IdleConnTimeout:       90 * time.Second,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   10,
+}
+
+// defaultHTTPClient is shared by the clients created without WithHTTPClient
+var defaultHTTPClient = NewHTTPClient(DefaultTransportConfig)
+
+// NewHTTPClient returns an HTTP client configured by cfg. The client keeps the
+// connections alive, it should be shared by the clients of the process.
+func NewHTTPClient(cfg TransportConfig) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     !cfg.DisableHTTP2,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	if cfg.DisableHTTP2 {
+		// A non nil empty map disables the HTTP/2 upgrade
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: transport,
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+)
+
+// leaseLocker serializes the record set mutations across the webhook replicas.
+// Each record set is guarded by a coordination.k8s.io Lease, a lease held by a
+// dead replica is taken over once it expired. A released lease is deleted.
+type leaseLocker struct {
+	client        kubernetes.Interface
+	namespace     string
+	identity      string
+	leaseDuration time.Duration
+	renewDeadline time.Duration
+	retryPeriod   time.Duration
+}
+
+// leaseName returns a valid object name for the record set `name` of `zone`.
+func leaseName(zone, name string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%s", zone, name)))
+
+	return fmt.Sprintf("godaddy-webhook-%s", hex.EncodeToString(sum[:10]))
+}
+
+// Lock waits until the lease guarding the record set `name` of `zone` is acquired
+// or ctx is done. The returned function must be called to release the lease.
+func (l *leaseLocker) Lock(ctx context.Context, zone, name string) (func(), error) {
+	acquired := make(chan struct{})
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Namespace: l.namespace,
+			Name:      leaseName(zone, name),
+		},
+		Client: l.client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			// Each lock gets its own identity, the lease must not be shared
+			// by two mutations running in the same replica.
+			Identity: fmt.Sprintf("%s_%s", l.identity, uuid.NewUUID()),
+		},
+	}
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		Name:            lock.LeaseMeta.Name,
+		LeaseDuration:   l.leaseDuration,
+		RenewDeadline:   l.renewDeadline,
+		RetryPeriod:     l.retryPeriod,
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) {
+				close(acquired)
+			},
+			OnStoppedLeading: func() {},
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		elector.Run(runCtx)
+	}()
+
+	stop := func() {
+		cancel()
+		<-done
+
+		l.delete(lock.LeaseMeta.Name, lock.LockConfig.Identity)
+	}
+
+	select {
+	case <-acquired:
+		return stop, nil
+	case <-done:
+		cancel()
+
+		return nil, fmt.Errorf("lease %s/%s for record %s on zone %s lost before use", l.namespace, lock.LeaseMeta.Name, name, zone)
+	case <-ctx.Done():
+		stop()
+
+		return nil, fmt.Errorf("unable to acquire lease %s/%s for record %s on zone %s: %w", l.namespace, lock.LeaseMeta.Name, name, zone, ctx.Err())
+	}
+}
+
+// delete removes the lease once released by identity, unless another replica acquired it meanwhile.
+// A lease left behind is taken over by the next mutation on the record set.
+func (l *leaseLocker) delete(name, identity string) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.renewDeadline)
+	defer cancel()
+
+	leases := l.client.CoordinationV1().Leases(l.namespace)
+
+	lease, err := leases.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Warningf("Unable to get lease %s/%s to delete it: %v", l.namespace, name, err)
+		}
+
+		return
+	}
+
+	if holder := lease.Spec.HolderIdentity; holder != nil && *holder != "" && *holder != identity {
+		return
+	}
+
+	err = leases.Delete(ctx, name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{
+			UID:             &lease.UID,
+			ResourceVersion: &lease.ResourceVersion,
+		},
+	})
+
+	if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+		klog.Warningf("Unable to delete lease %s/%s: %v", l.namespace, name, err)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestLeaseLocker(client *fake.Clientset, identity string) *leaseLocker {
+	return &leaseLocker{
+		client:        client,
+		namespace:     "cert-manager",
+		identity:      identity,
+		leaseDuration: 2 * time.Second,
+		renewDeadline: time.Second,
+		retryPeriod:   100 * time.Millisecond,
+	}
+}
+
+func TestLeaseLockerSerializesReplicas(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	first := newTestLeaseLocker(client, "replica-1")
+	second := newTestLeaseLocker(client, "replica-2")
+
+	unlock, err := first.Lock(context.Background(), "example.com", "_acme-challenge")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	if _, err := second.Lock(ctx, "example.com", "_acme-challenge"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got: %v", err)
+	}
+
+	if _, err := client.CoordinationV1().Leases("cert-manager").Get(context.Background(), leaseName("example.com", "_acme-challenge"), metav1.GetOptions{}); err != nil {
+		t.Fatalf("the lease of the holder must be kept by a waiter giving up: %v", err)
+	}
+
+	other, err := second.Lock(context.Background(), "example.com", "_acme-challenge.www")
+	if err != nil {
+		t.Fatalf("lock on another record must not wait: %v", err)
+	}
+
+	other()
+	unlock()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if unlock, err = second.Lock(ctx, "example.com", "_acme-challenge"); err != nil {
+		t.Fatalf("lease must be acquired once released: %v", err)
+	}
+
+	unlock()
+
+	if leases, err := client.CoordinationV1().Leases("cert-manager").List(context.Background(), metav1.ListOptions{}); err != nil || len(leases.Items) != 0 {
+		t.Fatalf("expected the released leases to be deleted, got: %d, error: %v", len(leases.Items), err)
+	}
+}
+
+func TestLeaseLockerStealsExpiredLease(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	dead := newTestLeaseLocker(client, "dead-replica")
+
+	unlock, err := dead.Lock(context.Background(), "example.com", "_acme-challenge")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a dead replica: the lease is neither renewed nor released.
+	lease, err := client.CoordinationV1().Leases("cert-manager").Get(context.Background(), leaseName("example.com", "_acme-challenge"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unlock()
+
+	lease.ResourceVersion = ""
+
+	if _, err = client.CoordinationV1().Leases("cert-manager").Create(context.Background(), lease, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if unlock, err = newTestLeaseLocker(client, "replica").Lock(ctx, "example.com", "_acme-challenge"); err != nil {
+		t.Fatalf("expired lease must be taken over: %v", err)
+	}
+
+	unlock()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// recordLocker serializes the read-modify-write mutations done on a GoDaddy
+// record set. Mutations on different keys are not blocked by each other.
+// The zero value is ready to use.
+type recordLocker struct {
+	mu    sync.Mutex
+	locks map[string]*recordLock
+}
+
+type recordLock struct {
+	sem  chan struct{}
+	refs int
+}
+
+// recordLockKey returns the key identifying the record set `name` of `zone` for account.
+func recordLockKey(account, zone, name string) string {
+	return fmt.Sprintf("%s/%s/%s", account, zone, name)
+}
+
+// Lock waits until the lock on key is acquired or ctx is done.
+// The returned function must be called to release the lock.
+func (l *recordLocker) Lock(ctx context.Context, key string) (func(), error) {
+	l.mu.Lock()
+
+	if l.locks == nil {
+		l.locks = make(map[string]*recordLock)
+	}
+
+	lock, found := l.locks[key]
+	if !found {
+		lock = &recordLock{
+			sem: make(chan struct{}, 1),
+		}
+
+		l.locks[key] = lock
+	}
+
+	lock.refs++
+
+	l.mu.Unlock()
+
+	select {
+	case lock.sem <- struct{}{}:
+		return func() {
+			<-lock.sem
+			l.release(key, lock)
+		}, nil
+	case <-ctx.Done():
+		l.release(key, lock)
+
+		return nil, fmt.Errorf("unable to lock record %s: %w", key, ctx.Err())
+	}
+}
+
+func (l *recordLocker) release(key string, lock *recordLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if lock.refs--; lock.refs == 0 {
+		delete(l.locks, key)
+	}
+}
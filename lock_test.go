@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecordLockerSerializesSameKey(t *testing.T) {
+	var locker recordLocker
+
+	unlock, err := locker.Lock(context.Background(), "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := locker.Lock(ctx, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got: %v", err)
+	}
+
+	other, err := locker.Lock(context.Background(), "b")
+	if err != nil {
+		t.Fatalf("lock on another key must not wait: %v", err)
+	}
+
+	other()
+	unlock()
+
+	if unlock, err = locker.Lock(context.Background(), "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock()
+
+	if len(locker.locks) != 0 {
+		t.Fatalf("expected no remaining locks, got: %d", len(locker.locks))
+	}
+}
+
+func TestConcurrentChallenges(t *testing.T) {
+	const count = 25
+
+	api := newFakeGoDaddy(t)
+
+	stubSolver(t, staticZone("example.com."))
+
+	solver := &godaddyDNSProviderSolver{}
+
+	config := fmt.Sprintf(`{"apiKeySecretRef":{"key":"key","secret":"secret"},"ttl":600,"apiURL":%q}`, api.URL)
+
+	run := func(action func(*godaddyDNSProviderSolver, int) error) {
+		var wg sync.WaitGroup
+
+		errs := make(chan error, count)
+
+		for i := 0; i < count; i++ {
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+
+				errs <- action(solver, i)
+			}(i)
+		}
+
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	run(func(s *godaddyDNSProviderSolver, i int) error {
+		return s.Present(newChallengeRequest("_acme-challenge.example.com.", "example.com.", fmt.Sprintf("key-%d", i), config))
+	})
+
+	if records := api.get("example.com", "TXT", "_acme-challenge"); len(records) != count {
+		t.Fatalf("expected %d TXT values, got: %d", count, len(records))
+	}
+
+	run(func(s *godaddyDNSProviderSolver, i int) error {
+		return s.CleanUp(newChallengeRequest("_acme-challenge.example.com.", "example.com.", fmt.Sprintf("key-%d", i), config))
+	})
+
+	if records := api.get("example.com", "TXT", "_acme-challenge"); len(records) != 0 {
+		t.Fatalf("expected no TXT value, got: %d", len(records))
+	}
+}
@@ -1,22 +1,25 @@
 package main
 
 import (
-	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	extapi "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/component-base/logs"
 	"k8s.io/klog/v2"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -24,6 +27,7 @@ import (
 	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
 	"github.com/cert-manager/cert-manager/pkg/issuer/acme/dns/util"
 
+	"github.com/Fred78290/cert-manager-webhook-godaddy/godaddy"
 	"github.com/Fred78290/cert-manager-webhook-godaddy/utils"
 	"github.com/cert-manager/cert-manager/pkg/acme/webhook"
 	"github.com/cert-manager/cert-manager/pkg/acme/webhook/cmd/server"
@@ -50,21 +54,43 @@ func NewContext(timeout time.Duration) Context {
 	}
 }
 
-// DNSRecord a DNS record
-type DNSRecord struct {
-	Type     string  `json:"type"`
-	Name     string  `json:"name"`
-	Data     string  `json:"data"`
-	TTL      int     `json:"ttl,omitempty"`
-	Priority *int    `json:"priority,omitempty"`
-	Weight   *int    `json:"weight,omitempty"`
-	Protocol *string `json:"protocol,omitempty"`
-	Service  *string `json:"service,omitempty"`
-}
-
 // GroupName a API group name
 var GroupName = os.Getenv("GROUP_NAME")
 
+var recordLockTimeout = flag.Duration("record-lock-timeout", utils.DefaultRecordLockTimeout, "Maximum time to wait for a pending mutation on the same record")
+var leaseLockEnabled = flag.Bool("lease-lock", false, "Serialize the record mutations across replicas with coordination.k8s.io Leases")
+var leaseLockNamespace = flag.String("lease-lock-namespace", utils.GetEnv("POD_NAMESPACE", utils.DefaultLeaderElectionNamespace), "Namespace where the record Leases are created")
+var leaseLockDuration = flag.Duration("lease-lock-duration", utils.DefaultRecordLeaseDuration, "Duration after which a record Lease not renewed by a replica can be taken over")
+var leaseLockRenewDeadline = flag.Duration("lease-lock-renew-deadline", utils.DefaultRecordLeaseRenewDeadline, "Duration the holder of a record Lease retries to renew it before giving up")
+var leaseLockRetryPeriod = flag.Duration("lease-lock-retry-period", utils.DefaultRecordLeaseRetryPeriod, "Duration between two attempts to acquire or renew a record Lease")
+var apiMaxRetries = flag.Int("api-max-retries", godaddy.DefaultRetryPolicy.MaxRetries, "Maximum number of retries of an idempotent GoDaddy API call failing with a transient error")
+var apiMinBackoff = flag.Duration("api-min-backoff", godaddy.DefaultRetryPolicy.MinBackoff, "Delay before the first retry of a GoDaddy API call, doubled on each retry")
+var apiMaxBackoff = flag.Duration("api-max-backoff", godaddy.DefaultRetryPolicy.MaxBackoff, "Maximum delay between two retries of a GoDaddy API call")
+var apiRateLimit = flag.Float64("api-rate-limit", godaddy.DefaultRateLimit.RequestsPerMinute, "Maximum number of GoDaddy API calls per minute and API key, 0 disables the throttling")
+var apiRateBurst = flag.Int("api-rate-burst", godaddy.DefaultRateLimit.Burst, "Number of GoDaddy API calls per API key which can be sent at once")
+var httpTimeout = flag.Duration("http-timeout", utils.GetEnvDuration("GODADDY_HTTP_TIMEOUT", godaddy.DefaultTransportConfig.Timeout), "Timeout of a GoDaddy API call, env GODADDY_HTTP_TIMEOUT")
+var httpDialTimeout = flag.Duration("http-dial-timeout", utils.GetEnvDuration("GODADDY_HTTP_DIAL_TIMEOUT", godaddy.DefaultTransportConfig.DialTimeout), "Timeout to establish a connection to the GoDaddy API, env GODADDY_HTTP_DIAL_TIMEOUT")
+var httpTLSHandshakeTimeout = flag.Duration("http-tls-handshake-timeout", utils.GetEnvDuration("GODADDY_HTTP_TLS_HANDSHAKE_TIMEOUT", godaddy.DefaultTransportConfig.TLSHandshakeTimeout), "Timeout of the TLS handshake with the GoDaddy API, env GODADDY_HTTP_TLS_HANDSHAKE_TIMEOUT")
+var httpResponseHeaderTimeout = flag.Duration("http-response-header-timeout", utils.GetEnvDuration("GODADDY_HTTP_RESPONSE_HEADER_TIMEOUT", godaddy.DefaultTransportConfig.ResponseHeaderTimeout), "Timeout to receive the response headers of a GoDaddy API call, env GODADDY_HTTP_RESPONSE_HEADER_TIMEOUT")
+var httpIdleConnTimeout = flag.Duration("http-idle-conn-timeout", utils.GetEnvDuration("GODADDY_HTTP_IDLE_CONN_TIMEOUT", godaddy.DefaultTransportConfig.IdleConnTimeout), "Time an idle connection to the GoDaddy API is kept open, env GODADDY_HTTP_IDLE_CONN_TIMEOUT")
+var httpMaxIdleConns = flag.Int("http-max-idle-conns", utils.GetEnvInt("GODADDY_HTTP_MAX_IDLE_CONNS", godaddy.DefaultTransportConfig.MaxIdleConns), "Size of the idle connection pool, env GODADDY_HTTP_MAX_IDLE_CONNS")
+var httpMaxIdleConnsPerHost = flag.Int("http-max-idle-conns-per-host", utils.GetEnvInt("GODADDY_HTTP_MAX_IDLE_CONNS_PER_HOST", godaddy.DefaultTransportConfig.MaxIdleConnsPerHost), "Size of the idle connection pool per host, env GODADDY_HTTP_MAX_IDLE_CONNS_PER_HOST")
+var httpDisableHTTP2 = flag.Bool("http-disable-http2", utils.GetEnvBool("GODADDY_HTTP_DISABLE_HTTP2", godaddy.DefaultTransportConfig.DisableHTTP2), "Use HTTP/1.1 to reach the GoDaddy API, env GODADDY_HTTP_DISABLE_HTTP2")
+var clusterResourceNamespace = flag.String("cluster-resource-namespace", utils.GetEnv("CLUSTER_RESOURCE_NAMESPACE", utils.DefaultClusterResourceNamespace), "Cluster resource namespace of cert-manager, where the Secrets referenced by a ClusterIssuer are read by default, env CLUSTER_RESOURCE_NAMESPACE")
+var secretNamespacePolicy = flag.String("secret-namespace-policy", utils.GetEnv("SECRET_NAMESPACE_POLICY", secretNamespacePolicyCluster), "Which issuers may reference a Secret of another namespace: same (none), cluster (ClusterIssuers only) or any, env SECRET_NAMESPACE_POLICY")
+var allowInlineCredentials = flag.Bool("allow-inline-credentials", utils.GetEnvBool("GODADDY_ALLOW_INLINE_CREDENTIALS", false), "Accept the deprecated API key and secret written in apiKeySecretRef without name, env GODADDY_ALLOW_INLINE_CREDENTIALS")
+var apiKeyFile = flag.String("api-key-file", utils.GetEnv("GODADDY_API_KEY_FILE", ""), "File holding the ambient API key, takes precedence over GODADDY_API_KEY, env GODADDY_API_KEY_FILE")
+var apiSecretFile = flag.String("api-secret-file", utils.GetEnv("GODADDY_API_SECRET_FILE", ""), "File holding the ambient API secret, takes precedence over GODADDY_API_SECRET, env GODADDY_API_SECRET_FILE")
+var accountCacheTTL = flag.Duration("account-cache-ttl", utils.DefaultAccountCacheTTL, "Duration the account owning a domain is cached when discoverAccounts is set")
+var secretNamespaces = flag.String("secret-namespaces", utils.GetEnv("GODADDY_SECRET_NAMESPACES", ""), "Comma separated list of the namespaces where Secrets are read, any namespace when empty, env GODADDY_SECRET_NAMESPACES")
+var domainCacheTTL = flag.Duration("domain-cache-ttl", utils.DefaultDomainCacheTTL, "Duration the domains of a GoDaddy account are cached when zoneResolution is api")
+
+// findZoneByFqdn is replaced in tests to not query DNS
+var findZoneByFqdn = util.FindZoneByFqdn
+
+// recursiveNameservers are the nameservers used for the DNS lookups, replaced in tests
+var recursiveNameservers = util.RecursiveNameservers
+
 func runWebhookServer(groupName string, hooks ...webhook.Solver) {
 	stopCh, exit := utils.SetupExitHandler(utils.GracefulShutdown)
 	defer exit() // This function might call os.Exit, so defer last
@@ -115,7 +141,32 @@ type godaddyDNSProviderSolver struct {
 	// 3. uncomment the relevant code in the Initialize method below
 	// 4. ensure your webhook's service account has the required RBAC role
 	//    assigned to it for interacting with the Kubernetes APIs you need.
-	client *kubernetes.Clientset
+	client kubernetes.Interface
+
+	// secrets caches the Secrets holding the credentials
+	secrets *secretCache
+
+	// ambient the default credentials of the webhook, nil if none
+	ambient *ambientCredentials
+
+	// locker serializes the mutations on the same record set
+	locker recordLocker
+
+	// lease serializes the mutations on the same record set across replicas, nil if disabled
+	lease *leaseLocker
+
+	// clients caches the GoDaddy API clients by account
+	clients sync.Map
+
+	// owners caches the account owning each domain when the accounts are discovered
+	owners ownerCache
+
+	// domains caches the domains of each account
+	domains domainCache
+
+	// httpClient is shared by the GoDaddy API clients, built on first use
+	httpClient     *http.Client
+	httpClientOnce sync.Once
 }
 
 // LocalObjectReference A reference to an object in the same namespace as the referent.
@@ -142,6 +193,39 @@ type SecretKeySelector struct {
 	// +optional
 	Key    string `json:"key,omitempty"`
 	Secret string `json:"secret,omitempty"`
+
+	// The namespace of the Secret resource, the namespace of the issuer when empty.
+	// Referencing another namespace is subject to --secret-namespace-policy.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// SecretKeyRef A reference to an entry of a Secret resource
+type SecretKeyRef struct {
+	// The name of the Secret resource being referred to.
+	Name string `json:"name"`
+
+	// The entry of the Secret resource's `data` field, defaulted by the field using the reference.
+	// +optional
+	Key string `json:"key,omitempty"`
+
+	// The namespace of the Secret resource, the namespace of the issuer when empty.
+	// Referencing another namespace is subject to --secret-namespace-policy.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+const (
+	// defaultAPIKeyEntry the entry of the Secret holding the API key when the reference has no key
+	defaultAPIKeyEntry = "key"
+	// defaultAPISecretEntry the entry of the Secret holding the API secret when the reference has no key
+	defaultAPISecretEntry = "secret"
+)
+
+// credentialRef a reference to a credential and the config field holding it
+type credentialRef struct {
+	field string
+	SecretKeyRef
 }
 
 // godaddyDNSProviderConfig is a structure that is used to decode into when
@@ -162,20 +246,261 @@ type godaddyDNSProviderConfig struct {
 	// These fields will be set by users in the
 	// `issuer.spec.acme.dns01.providers.webhook.config` field.
 
+	// APIKeyRef and APISecretRef reference the API key and secret, by default in
+	// the entries `key` and `secret`. The ambient credentials of the webhook are used
+	// when no credentials are configured and cert-manager allows it.
+	// +optional
+	APIKeyRef    *SecretKeyRef `json:"apiKeyRef,omitempty"`
+	APISecretRef *SecretKeyRef `json:"apiSecretRef,omitempty"`
+
+	// APIKeySecretRef deprecated, use APIKeyRef and APISecretRef.
+	// With a name, `key` and `secret` are the entries of the Secret holding the API key and secret,
+	// without it they are the API key and secret themselves, see --allow-inline-credentials.
 	APIKeySecretRef SecretKeySelector `json:"apiKeySecretRef"`
-	Production      bool              `json:"production"`
-	TTL             int               `json:"ttl"`
+
+	// CredentialsSecretRef references a Secret entry, by default `credentials`, holding
+	// the JSON document {"key":"…","secret":"…","shopperId":"…"} or the pair `key:secret`.
+	// The shopper id of the document is used when the config sets none.
+	// +optional
+	CredentialsSecretRef *SecretKeyRef `json:"credentialsSecretRef,omitempty"`
+
+	// Accounts routes the challenges to several GoDaddy accounts, the credentials of the
+	// entry whose domain is the longest suffix of the challenge FQDN are used, or those of
+	// the entry without domains. It replaces the other credential fields.
+	// +optional
+	Accounts []accountConfig `json:"accounts,omitempty"`
+
+	// DiscoverAccounts finds the entry of Accounts owning the zone of the challenge with
+	// GET /v1/domains/{domain}, when no entry has a domain matching the challenge.
+	// +optional
+	DiscoverAccounts bool `json:"discoverAccounts,omitempty"`
+
+	Production bool `json:"production"`
+	TTL        int  `json:"ttl"`
+
+	// Environment selects the GoDaddy endpoint: production, ote or custom.
+	// It takes precedence over Production.
+	// +optional
+	Environment string `json:"environment,omitempty"`
+
+	// APIURL is the endpoint of the GoDaddy API, e.g. an API gateway.
+	// It takes precedence over Environment and Production.
+	// +optional
+	APIURL string `json:"apiURL,omitempty"`
+
+	// ZoneResolution selects how the zone hosting the record is found:
+	// dns (default) with a SOA lookup, api from the domains of the account,
+	// certManager from the zone resolved by cert-manager or static from Zones and Zone.
+	// +optional
+	ZoneResolution string `json:"zoneResolution,omitempty"`
+
+	// FollowCNAME writes the TXT record at the final target of the CNAME chain
+	// starting at the challenge fqdn, in the GoDaddy zone hosting it.
+	// +optional
+	FollowCNAME bool `json:"followCNAME,omitempty"`
+
+	// WaitForPropagation makes Present wait until every authoritative nameserver
+	// of the zone serves the TXT record.
+	// +optional
+	WaitForPropagation bool `json:"waitForPropagation,omitempty"`
+
+	// PropagationTimeout bounds the Present call waiting for propagation, 45s if not set.
+	// Keep it below the request timeout of the kube-apiserver, 60s by default.
+	// +optional
+	PropagationTimeout *metav1.Duration `json:"propagationTimeout,omitempty"`
+
+	// PropagationInterval is the delay between two propagation checks, 10s if not set
+	// +optional
+	PropagationInterval *metav1.Duration `json:"propagationInterval,omitempty"`
+
+	// Zone is the zone hosting the records when zoneResolution is static
+	// and no entry of Zones matches.
+	// +optional
+	Zone string `json:"zone,omitempty"`
+
+	// Zones maps domain suffixes to the zone hosting their records when
+	// zoneResolution is static. The longest matching suffix wins.
+	// +optional
+	Zones map[string]string `json:"zones,omitempty"`
+
+	// ShopperID is the reseller's sub-account owning the domains, sent as X-Shopper-Id.
+	// +optional
+	ShopperID string `json:"shopperId,omitempty"`
+
+	// ShopperIDSecretRef references the shopper id stored in a Secret,
+	// `key` is the entry of the Secret's `data` field. It takes precedence over ShopperID.
+	// +optional
+	ShopperIDSecretRef *SecretKeyRef `json:"shopperIdSecretRef,omitempty"`
+}
+
+const (
+	environmentProduction = "production"
+	environmentOTE        = "ote"
+	environmentCustom     = "custom"
+)
+
+// validate checks the settings which can't be verified by decoding
+func (c godaddyDNSProviderConfig) validate() error {
+	switch c.Environment {
+	case "", environmentProduction, environmentOTE:
+		if c.APIURL != "" && c.Environment != "" {
+			return fmt.Errorf("apiURL can't be used with environment: %s, use environment: %s", c.Environment, environmentCustom)
+		}
+	case environmentCustom:
+		if c.APIURL == "" {
+			return fmt.Errorf("apiURL is required with environment: %s", environmentCustom)
+		}
+	default:
+		return fmt.Errorf("unsupported environment: %s, expected one of %s, %s or %s", c.Environment, environmentProduction, environmentOTE, environmentCustom)
+	}
+
+	switch c.ZoneResolution {
+	case "", zoneResolutionDNS, zoneResolutionAPI, zoneResolutionCertManager:
+	case zoneResolutionStatic:
+		if c.Zone == "" && len(c.Zones) == 0 {
+			return fmt.Errorf("zoneResolution %s requires zone or zones", zoneResolutionStatic)
+		}
+	default:
+		return fmt.Errorf("unsupported zoneResolution: %s, expected one of %s, %s, %s or %s", c.ZoneResolution,
+			zoneResolutionDNS, zoneResolutionAPI, zoneResolutionCertManager, zoneResolutionStatic)
+	}
+
+	if (c.PropagationTimeout != nil && c.PropagationTimeout.Duration <= 0) || (c.PropagationInterval != nil && c.PropagationInterval.Duration <= 0) {
+		return fmt.Errorf("propagationTimeout and propagationInterval must be positive")
+	}
+
+	if err := c.validateCredentials(); err != nil {
+		return err
+	}
+
+	if err := c.validateAccounts(); err != nil {
+		return err
+	}
+
+	if c.APIURL != "" {
+		u, err := url.Parse(c.APIURL)
+		if err != nil {
+			return fmt.Errorf("invalid apiURL: %v", err)
+		}
+
+		if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("invalid apiURL: %s, expected an absolute http(s) URL without query", c.APIURL)
+		}
+	}
+
+	return nil
+}
+
+// usesAmbientCredentials reports whether the config has no credentials of its own
+func (c godaddyDNSProviderConfig) usesAmbientCredentials() bool {
+	return c.APIKeyRef == nil && c.CredentialsSecretRef == nil && c.APIKeySecretRef == (SecretKeySelector{})
+}
+
+// credentialSource describes where the credentials used for fqdn are read from
+func (c godaddyDNSProviderConfig) credentialSource(fqdn string) string {
+	if len(c.Accounts) > 0 {
+		if selected, matched := c.matchAccount(fqdn); matched || (selected >= 0 && !c.DiscoverAccounts) {
+			return fmt.Sprintf("%s of accounts[%d]", c.withAccount(c.Accounts[selected]).credentialSource(fqdn), selected)
+		}
+
+		return "the entry of accounts owning the zone"
+	}
+
+	switch {
+	case c.usesAmbientCredentials():
+		return "the ambient credentials of the webhook, GODADDY_API_KEY and GODADDY_API_SECRET or --api-key-file and --api-secret-file"
+	case c.CredentialsSecretRef != nil:
+		return "credentialsSecretRef"
+	case c.APIKeyRef != nil:
+		return "apiKeyRef and apiSecretRef"
+	case c.APIKeySecretRef.Name == nil:
+		return "the inline apiKeySecretRef"
+	}
+
+	return "apiKeySecretRef"
+}
+
+// apiCredentialRefs returns the references to the API key and secret, false when the
+// config has none. apiKeySecretRef with a name is converted into two references to
+// the entries `key` and `secret` of its Secret.
+func (c godaddyDNSProviderConfig) apiCredentialRefs() (credentialRef, credentialRef, bool) {
+	keyRef := credentialRef{field: "apiKeyRef"}
+	secretRef := credentialRef{field: "apiSecretRef"}
+
+	switch {
+	case c.APIKeyRef != nil:
+		keyRef.SecretKeyRef = *c.APIKeyRef
+		secretRef.SecretKeyRef = *c.APISecretRef
+	case c.APIKeySecretRef.Name != nil:
+		keyRef.field = "apiKeySecretRef"
+		secretRef.field = "apiKeySecretRef"
+		keyRef.SecretKeyRef = SecretKeyRef{Name: *c.APIKeySecretRef.Name, Key: c.APIKeySecretRef.Key, Namespace: c.APIKeySecretRef.Namespace}
+		secretRef.SecretKeyRef = SecretKeyRef{Name: *c.APIKeySecretRef.Name, Key: c.APIKeySecretRef.Secret, Namespace: c.APIKeySecretRef.Namespace}
+	default:
+		return keyRef, secretRef, false
+	}
+
+	if keyRef.Key == "" {
+		keyRef.Key = defaultAPIKeyEntry
+	}
+
+	if secretRef.Key == "" {
+		secretRef.Key = defaultAPISecretEntry
+	}
+
+	return keyRef, secretRef, true
+}
+
+// validateCredentials checks the credential fields are consistent
+func (c godaddyDNSProviderConfig) validateCredentials() error {
+	if (c.APIKeyRef == nil) != (c.APISecretRef == nil) {
+		return fmt.Errorf("apiKeyRef and apiSecretRef must be set together")
+	}
+
+	if c.APIKeyRef != nil {
+		if c.APIKeyRef.Name == "" || c.APISecretRef.Name == "" {
+			return fmt.Errorf("apiKeyRef and apiSecretRef require a name")
+		}
+
+		if c.APIKeySecretRef != (SecretKeySelector{}) {
+			return fmt.Errorf("apiKeySecretRef can't be used with apiKeyRef and apiSecretRef")
+		}
+	}
+
+	if c.CredentialsSecretRef != nil {
+		if c.CredentialsSecretRef.Name == "" {
+			return fmt.Errorf("credentialsSecretRef requires a name")
+		}
+
+		if c.APIKeyRef != nil || c.APIKeySecretRef != (SecretKeySelector{}) {
+			return fmt.Errorf("credentialsSecretRef can't be used with apiKeyRef, apiSecretRef or apiKeySecretRef")
+		}
+	}
+
+	if c.ShopperIDSecretRef != nil && (c.ShopperIDSecretRef.Name == "" || c.ShopperIDSecretRef.Key == "") {
+		return fmt.Errorf("shopperIdSecretRef requires a name and a key")
+	}
+
+	return nil
 }
 
 func (c godaddyDNSProviderConfig) goDaddyURL() string {
 	// https://developer.godaddy.com/doc/endpoint/domains
 	// OTE environment: https://api.ote-godaddy.com
 	// PRODUCTION environment: https://api.godaddy.com
-	if c.Production {
-		return "https://api.godaddy.com"
+	// apiURL takes precedence over environment which takes precedence over production
+	switch {
+	case c.APIURL != "":
+		return c.APIURL
+	case c.Environment == environmentProduction:
+		return godaddy.ProductionURL
+	case c.Environment == environmentOTE:
+		return godaddy.OTEURL
+	case c.Production:
+		return godaddy.ProductionURL
 	}
 
-	return "https://api.ote-godaddy.com"
+	return godaddy.OTEURL
 }
 
 // Name is used as the name for this DNS solver when referencing it on the ACME
@@ -194,6 +519,8 @@ func (c *godaddyDNSProviderSolver) Name() string {
 // cert-manager itself will later perform a self check to ensure that the
 // solver has correctly configured the DNS provider.
 func (c *godaddyDNSProviderSolver) Present(ch *v1alpha1.ChallengeRequest) error {
+	start := time.Now()
+
 	cfg, err := loadConfig(ch.Config)
 	if err != nil {
 		return err
@@ -201,82 +528,91 @@ func (c *godaddyDNSProviderSolver) Present(ch *v1alpha1.ChallengeRequest) error
 
 	klog.V(4).Infof("Decoded configuration %v", cfg)
 
-	recordName := c.extractRecordName(ch.ResolvedFQDN, ch.ResolvedZone)
+	ctx := NewContext(120)
+	defer ctx.cancel()
 
-	dnsZone, err := c.getZone(ch.ResolvedFQDN)
-	if err != nil {
-		klog.Errorf("Unable to get zone:%s, error: %v", ch.ResolvedZone, err)
-		return err
-	}
+	return c.withRecord(ctx.ctx, cfg, ch, func(client *godaddy.Client, dnsZone, recordName string) error {
+		rec := godaddy.DNSRecord{
+			Type: "TXT",
+			Name: recordName,
+			Data: ch.Key,
+			TTL:  cfg.TTL,
+		}
 
-	rec := DNSRecord{
-		Type: "TXT",
-		Name: recordName,
-		Data: ch.Key,
-		TTL:  cfg.TTL,
-	}
+		klog.Infof("Present record: %s on zone: %s with key: %s, shopper: %s", recordName, dnsZone, ch.Key, client.ShopperID())
 
-	klog.Infof("Present record: %s on zone: %s with key: %s", recordName, dnsZone, ch.Key)
+		unlock, err := c.lockRecord(ctx.ctx, client.Account(), dnsZone, recordName)
+		if err != nil {
+			return err
+		}
 
-	return c.addRecord(cfg, ch.ResourceNamespace, rec, dnsZone, recordName)
-}
+		err = c.addRecord(ctx.ctx, client, dnsZone, rec)
 
-func (c *godaddyDNSProviderSolver) getAllRecords(authAPIKey, authAPISecret, baseURL, domainZone string) ([]DNSRecord, error) {
-	var records []DNSRecord
+		unlock()
 
-	url := fmt.Sprintf("/v1/domains/%s/records", domainZone)
-	resp, err := c.makeRequest(authAPIKey, authAPISecret, baseURL, http.MethodGet, url, nil)
-	if err != nil {
-		klog.Errorf("Unable to request: %s%s, got error:%s", baseURL, url, err)
+		if err != nil || !cfg.WaitForPropagation {
+			return err
+		}
 
-		return nil, err
-	}
+		return c.waitForPropagation(ctx.ctx, cfg, start, dnsZone, recordName, ch.Key)
+	})
+}
 
-	defer resp.Body.Close()
+// withRecord runs action with the client and the record of the challenge.
+// When the discovered owner of the zone answers 404 or 403, e.g. after a transfer,
+// the owner is discovered again and action is retried once.
+func (c *godaddyDNSProviderSolver) withRecord(ctx context.Context, cfg godaddyDNSProviderConfig, ch *v1alpha1.ChallengeRequest, action func(client *godaddy.Client, dnsZone, recordName string) error) error {
+	source := cfg.credentialSource(ch.ResolvedFQDN)
 
-	bodyBytes, _ := io.ReadAll(resp.Body)
+	for attempt := 1; ; attempt++ {
+		client, ownerZone, err := c.getClient(cfg, ch)
+		if err != nil {
+			return err
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		errStr := fmt.Sprintf("Unable to list records for zone: %s; Status: %v; Body: %s", domainZone, resp.StatusCode, string(bodyBytes))
+		dnsZone, recordName, err := c.resolveRecord(ctx, cfg, client, ch)
+		if err != nil {
+			klog.Errorf("Unable to resolve record: %s, error: %v", ch.ResolvedFQDN, err)
 
-		klog.Errorln(errStr)
+			return explainError(err, util.UnFqdn(ch.ResolvedZone), source)
+		}
 
-		return nil, fmt.Errorf(errStr)
-	}
+		err = action(client, dnsZone, recordName)
 
-	if err := json.Unmarshal(bodyBytes, &records); err != nil {
-		klog.Errorf("Can't decode config: %v", err)
+		if c.forgetOwner(cfg, client, ownerZone, err) && attempt == 1 {
+			continue
+		}
 
-		return nil, fmt.Errorf("error decoding solver config: %v", err)
+		return explainError(err, dnsZone, source)
 	}
-
-	return records, nil
 }
 
-func (c *godaddyDNSProviderSolver) deleteRecord(authAPIKey, authAPISecret, baseURL, domainZone string, record *DNSRecord) error {
-	var body []byte
+// waitForPropagation waits until the authoritative nameservers of domainZone serve
+// the TXT value at recordName. The propagation timeout of cfg bounds the whole
+// Present call started at start: cert-manager calls the webhook through the
+// kube-apiserver, which gives up on the request after 60s by default.
+func (c *godaddyDNSProviderSolver) waitForPropagation(ctx context.Context, cfg godaddyDNSProviderConfig, start time.Time, domainZone, recordName, value string) error {
+	timeout := utils.DefaultPropagationTimeout
+	interval := utils.DefaultPropagationInterval
 
-	url := fmt.Sprintf("/v1/domains/%s/records/%s/%s", domainZone, record.Type, record.Name)
-
-	resp, err := c.makeRequest(authAPIKey, authAPISecret, baseURL, http.MethodDelete, url, bytes.NewReader(body))
-	if err != nil {
-		klog.Errorf("Unable to request: %s%s, got error:%s", baseURL, url, err)
-
-		return err
+	if cfg.PropagationTimeout != nil {
+		timeout = cfg.PropagationTimeout.Duration
 	}
 
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusNoContent {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		errStr := fmt.Sprintf("Unable to delete records for zone: %s; Status: %v; Body: %s", domainZone, resp.StatusCode, string(bodyBytes))
+	if cfg.PropagationInterval != nil {
+		interval = cfg.PropagationInterval.Duration
+	}
 
-		klog.Errorln(errStr)
+	fqdn := util.ToFqdn(domainZone)
 
-		return fmt.Errorf(errStr)
+	if recordName != "@" {
+		fqdn = util.ToFqdn(recordName + "." + domainZone)
 	}
 
-	return nil
+	ctx, cancel := context.WithDeadline(ctx, start.Add(timeout))
+	defer cancel()
+
+	return waitForPropagation(ctx, domainZone, fqdn, value, interval)
 }
 
 // CleanUp should delete the relevant TXT record from the DNS provider console.
@@ -286,51 +622,40 @@ func (c *godaddyDNSProviderSolver) deleteRecord(authAPIKey, authAPISecret, baseU
 // This is in order to facilitate multiple DNS validations for the same domain
 // concurrently.
 func (c *godaddyDNSProviderSolver) CleanUp(ch *v1alpha1.ChallengeRequest) error {
-	var records []DNSRecord
-
 	cfg, err := loadConfig(ch.Config)
 	if err != nil {
 		return err
 	}
 
-	authAPIKey, authAPISecret, err := c.getAPIKey(cfg, ch.ResourceNamespace)
-	if err != nil {
-		return err
-	}
-
-	baseURL := cfg.goDaddyURL()
-
 	klog.V(4).Infof("Decoded configuration %v", cfg)
 
-	recordName := c.extractRecordName(ch.ResolvedFQDN, ch.ResolvedZone)
-
-	dnsZone, err := c.getZone(ch.ResolvedFQDN)
-	if err != nil {
-		klog.Errorf("Unable to get zone:%s, error: %v", ch.ResolvedZone, err)
-		return err
-	}
+	ctx := NewContext(120)
+	defer ctx.cancel()
 
-	klog.Infof("Cleanup record: %s on zone: %s with key: %s", recordName, dnsZone, ch.Key)
+	return c.withRecord(ctx.ctx, cfg, ch, func(client *godaddy.Client, dnsZone, recordName string) error {
+		klog.Infof("Cleanup record: %s on zone: %s with key: %s, shopper: %s", recordName, dnsZone, ch.Key, client.ShopperID())
 
-	if records, err = c.getAllRecords(*authAPIKey, *authAPISecret, baseURL, dnsZone); err != nil {
-		klog.Errorf("Unable to fetch records from zone:%s, error: %v", dnsZone, err)
-		return err
-	}
+		rec := godaddy.DNSRecord{
+			Type: "TXT",
+			Name: recordName,
+			Data: ch.Key,
+		}
 
-	for _, record := range records {
-		if record.Name == recordName && record.Data == ch.Key && record.Type == "TXT" {
-			if err = c.deleteRecord(*authAPIKey, *authAPISecret, baseURL, dnsZone, &record); err == nil {
-				klog.Infof("Cleaned record: %s on zone: %s with key: %s", recordName, dnsZone, ch.Key)
-			} else {
-				klog.ErrorS(err, "Cleaned record: %s on zone: %s with key: %s", recordName, dnsZone, ch.Key)
-			}
+		unlock, err := c.lockRecord(ctx.ctx, client.Account(), dnsZone, recordName)
+		if err != nil {
 			return err
 		}
-	}
 
-	klog.Warningf("Record %s is not found in zone %s", recordName, dnsZone)
+		defer unlock()
 
-	return nil
+		if err = c.removeRecord(ctx.ctx, client, dnsZone, rec); err == nil {
+			klog.Infof("Cleaned record: %s on zone: %s with key: %s", recordName, dnsZone, ch.Key)
+		} else {
+			klog.Errorf("Unable to clean record: %s on zone: %s with key: %s, error: %v", recordName, dnsZone, ch.Key, err)
+		}
+
+		return err
+	})
 }
 
 // Initialize will be called when the webhook first starts.
@@ -348,7 +673,41 @@ func (c *godaddyDNSProviderSolver) Initialize(kubeClientConfig *rest.Config, sto
 		return err
 	}
 
+	if err := validateSecretNamespacePolicy(*secretNamespacePolicy); err != nil {
+		return err
+	}
+
+	c.ambient = &ambientCredentials{
+		apiKey:     os.Getenv("GODADDY_API_KEY"),
+		apiSecret:  os.Getenv("GODADDY_API_SECRET"),
+		keyFile:    *apiKeyFile,
+		secretFile: *apiSecretFile,
+	}
+
 	c.client = cl
+	c.secrets = newSecretCache(cl, parseNamespaces(*secretNamespaces), stopCh)
+
+	if *leaseLockEnabled {
+		identity, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+
+		c.lease = &leaseLocker{
+			client:        cl,
+			namespace:     *leaseLockNamespace,
+			identity:      utils.GetEnv("POD_NAME", identity),
+			leaseDuration: *leaseLockDuration,
+			renewDeadline: *leaseLockRenewDeadline,
+			retryPeriod:   *leaseLockRetryPeriod,
+		}
+
+		if *recordLockTimeout <= *leaseLockDuration+*leaseLockRetryPeriod {
+			klog.Warningf("--record-lock-timeout %s is not longer than --lease-lock-duration plus --lease-lock-retry-period, a lease left by a dead replica is only taken over on a later retry", *recordLockTimeout)
+		}
+
+		klog.Infof("Record mutations are serialized with leases in namespace: %s", *leaseLockNamespace)
+	}
 
 	return nil
 }
@@ -370,123 +729,353 @@ func loadConfig(cfgJSON *extapi.JSON) (godaddyDNSProviderConfig, error) {
 		return cfg, fmt.Errorf("error decoding solver config: %v", err)
 	}
 
+	if err := cfg.validate(); err != nil {
+		klog.Errorf("Invalid config: %v", err)
+
+		return cfg, fmt.Errorf("invalid solver config: %v", err)
+	}
+
 	return cfg, nil
 }
 
-func (c *godaddyDNSProviderSolver) addRecord(cfg godaddyDNSProviderConfig, resourceNamespace string, record DNSRecord, domainZone string, recordName string) error {
-	body, err := json.Marshal([]DNSRecord{record})
+// lockRecord serializes the mutations on the record set recordName of domainZone,
+// inside the process and across the replicas when lease locking is enabled.
+// The wait is bounded by the flag --record-lock-timeout.
+func (c *godaddyDNSProviderSolver) lockRecord(ctx context.Context, account, domainZone, recordName string) (func(), error) {
+	ctx, cancel := context.WithTimeout(ctx, *recordLockTimeout)
+	defer cancel()
+
+	start := time.Now()
+
+	unlock, err := c.locker.Lock(ctx, recordLockKey(account, domainZone, recordName))
 	if err != nil {
+		recordLockWaitDuration.WithLabelValues("process", "timeout").Observe(time.Since(start).Seconds())
+		klog.Errorf("Unable to lock record: %s on zone: %s, error: %v", recordName, domainZone, err)
+
+		return nil, err
+	}
+
+	recordLockWaitDuration.WithLabelValues("process", "acquired").Observe(time.Since(start).Seconds())
+
+	if c.lease == nil {
+		return unlock, nil
+	}
+
+	start = time.Now()
+
+	release, err := c.lease.Lock(ctx, domainZone, recordName)
+	if err != nil {
+		unlock()
+
+		recordLockWaitDuration.WithLabelValues("lease", "timeout").Observe(time.Since(start).Seconds())
+		klog.Errorf("Unable to lease record: %s on zone: %s, error: %v", recordName, domainZone, err)
+
+		return nil, err
+	}
+
+	recordLockWaitDuration.WithLabelValues("lease", "acquired").Observe(time.Since(start).Seconds())
+
+	return func() {
+		release()
+		unlock()
+	}, nil
+}
+
+// addRecord merges the record into the record set already published under its
+// name, so that other values (e.g. the key for a wildcard and its apex) are kept.
+func (c *godaddyDNSProviderSolver) addRecord(ctx context.Context, client *godaddy.Client, domainZone string, record godaddy.DNSRecord) error {
+	records, err := client.GetRecords(ctx, domainZone, record.Type, record.Name)
+	if err != nil {
+		klog.Errorln(err)
+
 		return err
 	}
 
-	authAPIKey, authAPISecret, e := c.getAPIKey(cfg, resourceNamespace)
-	if e != nil {
-		return e
+	for _, existing := range records {
+		if existing.Data == record.Data {
+			klog.Infof("Record: %s on zone: %s with key: %s already present", record.Name, domainZone, record.Data)
+
+			return nil
+		}
 	}
 
-	baseURL := cfg.goDaddyURL()
+	if err = client.ReplaceRecords(ctx, domainZone, record.Type, record.Name, append(records, record)); err != nil {
+		klog.Errorln(err)
+	}
+
+	return err
+}
 
-	var resp *http.Response
-	url := fmt.Sprintf("/v1/domains/%s/records/%s/%s", domainZone, record.Type, recordName)
-	resp, err = c.makeRequest(*authAPIKey, *authAPISecret, baseURL, http.MethodPut, url, bytes.NewReader(body))
+// removeRecord drops the value of record from the record set published under its
+// name. The remaining values are written back, the record set is only deleted
+// when no value is left.
+func (c *godaddyDNSProviderSolver) removeRecord(ctx context.Context, client *godaddy.Client, domainZone string, record godaddy.DNSRecord) error {
+	records, err := client.GetRecords(ctx, domainZone, record.Type, record.Name)
 	if err != nil {
-		klog.Errorf("Unable to request: %s%s, got error:%s", baseURL, url, err)
+		klog.Errorln(err)
 
 		return err
 	}
 
-	defer resp.Body.Close()
+	remaining := make([]godaddy.DNSRecord, 0, len(records))
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		errStr := fmt.Sprintf("could not create record %v; Status: %v; Body: %s", string(body), resp.StatusCode, string(bodyBytes))
+	for _, existing := range records {
+		if existing.Data != record.Data {
+			remaining = append(remaining, existing)
+		}
+	}
 
-		klog.Errorln(errStr)
+	if len(remaining) == len(records) {
+		klog.Warningf("Record %s with key: %s is not found in zone %s", record.Name, record.Data, domainZone)
 
-		return fmt.Errorf(errStr)
+		return nil
 	}
 
-	return nil
+	if len(remaining) == 0 {
+		// A retried DELETE whose first response was lost, or a concurrent CleanUp, finds nothing left
+		if err = client.DeleteRecords(ctx, domainZone, record.Type, record.Name); godaddy.IsNotFound(err) {
+			klog.Infof("Record %s already deleted from zone %s", record.Name, domainZone)
+
+			return nil
+		}
+	} else {
+		err = client.ReplaceRecords(ctx, domainZone, record.Type, record.Name, remaining)
+	}
+
+	if err != nil {
+		klog.Errorln(err)
+	}
+
+	return err
+}
+
+// explainError wraps an error returned by the GoDaddy API with a hint on how to fix it,
+// cert-manager reports it in the status of the Challenge. source tells where the credentials
+// were read from, see credentialSource.
+func explainError(err error, domainZone, source string) error {
+	switch {
+	case err == nil:
+		return nil
+	case godaddy.IsUnauthorized(err):
+		return fmt.Errorf("GoDaddy rejected the API credentials, check the key and secret read from %s and the production setting: %w", source, err)
+	case godaddy.IsAccessDenied(err):
+		return fmt.Errorf("GoDaddy denied the access to zone %s, check the account owns the domain and is allowed to use the DNS API: %w", domainZone, err)
+	case godaddy.IsNotFound(err):
+		return fmt.Errorf("zone %s is not managed by this GoDaddy account: %w", domainZone, err)
+	case godaddy.IsRateLimited(err):
+		return fmt.Errorf("GoDaddy throttled the requests on zone %s, the challenge will be retried: %w", domainZone, err)
+	case godaddy.IsValidationError(err):
+		return fmt.Errorf("GoDaddy rejected the TXT record on zone %s, check the ttl setting (minimum 600): %w", domainZone, err)
+	}
+
+	return err
+}
+
+// getHTTPClient returns the HTTP client shared by the GoDaddy API clients,
+// its transport is tuned by the --http-* flags.
+func (c *godaddyDNSProviderSolver) getHTTPClient() *http.Client {
+	c.httpClientOnce.Do(func() {
+		c.httpClient = godaddy.NewHTTPClient(godaddy.TransportConfig{
+			Timeout:               *httpTimeout,
+			DialTimeout:           *httpDialTimeout,
+			TLSHandshakeTimeout:   *httpTLSHandshakeTimeout,
+			ResponseHeaderTimeout: *httpResponseHeaderTimeout,
+			IdleConnTimeout:       *httpIdleConnTimeout,
+			MaxIdleConns:          *httpMaxIdleConns,
+			MaxIdleConnsPerHost:   *httpMaxIdleConnsPerHost,
+			DisableHTTP2:          *httpDisableHTTP2,
+		})
+	})
+
+	return c.httpClient
 }
 
-func (c *godaddyDNSProviderSolver) makeRequest(authAPIKey string, authAPISecret string, baseURL string, method string, uri string, body io.Reader) (*http.Response, error) {
-	req, err := http.NewRequest(method, fmt.Sprintf("%s%s", baseURL, uri), body)
+// getClient returns the GoDaddy API client for the account configured by cfg,
+// and the zone whose owner was discovered, empty unless discoverAccounts is set.
+// Clients are built once per account and endpoint.
+func (c *godaddyDNSProviderSolver) getClient(cfg godaddyDNSProviderConfig, ch *v1alpha1.ChallengeRequest) (*godaddy.Client, string, error) {
+	if cfg.DiscoverAccounts {
+		return c.discoverClient(cfg, ch)
+	}
+
+	cfg, err := cfg.selectAccount(ch.ResolvedFQDN)
+	if err != nil {
+		return nil, "", err
+	}
+
+	client, err := c.accountClient(cfg, ch)
+
+	return client, "", err
+}
+
+// accountClient returns the GoDaddy API client for the credentials of cfg
+func (c *godaddyDNSProviderSolver) accountClient(cfg godaddyDNSProviderConfig, ch *v1alpha1.ChallengeRequest) (*godaddy.Client, error) {
+	creds, err := c.getCredentials(cfg, ch)
 	if err != nil {
 		return nil, err
 	}
 
+	shopperID, err := c.getShopperID(cfg, ch.ResourceNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if shopperID == "" {
+		shopperID = creds.ShopperID
+	}
+
+	authAPIKey, authAPISecret := creds.APIKey, creds.APISecret
+
+	baseURL := cfg.goDaddyURL()
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%s:%s", baseURL, authAPIKey, authAPISecret, shopperID)))
+	key := hex.EncodeToString(sum[:])
+
+	if client, found := c.clients.Load(key); found {
+		return client.(*godaddy.Client), nil
+	}
+
 	userAgent := fmt.Sprintf("%s/%s (%s) cert-manager/%s",
 		"godaddy-webhook",
 		phVersion, pkgutil.VersionInfo().Platform, phBuildDate)
 
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("sso-key %s:%s", authAPIKey, authAPISecret))
+	retryPolicy := godaddy.RetryPolicy{
+		MaxRetries: *apiMaxRetries,
+		MinBackoff: *apiMinBackoff,
+		MaxBackoff: *apiMaxBackoff,
+	}
+
+	client, _ := c.clients.LoadOrStore(key, godaddy.NewClient(baseURL, authAPIKey, authAPISecret,
+		godaddy.WithUserAgent(userAgent),
+		godaddy.WithShopperID(shopperID),
+		godaddy.WithHTTPClient(c.getHTTPClient()),
+		godaddy.WithRetryPolicy(retryPolicy),
+		godaddy.WithRateLimit(godaddy.RateLimit{
+			RequestsPerMinute: *apiRateLimit,
+			Burst:             *apiRateBurst,
+		})))
+
+	return client.(*godaddy.Client), nil
+}
+
+// getCredentials returns the API key and secret of the issuer, or the ambient ones
+// of the webhook when the issuer has none and cert-manager allows it to use them
+func (c *godaddyDNSProviderSolver) getCredentials(cfg godaddyDNSProviderConfig, ch *v1alpha1.ChallengeRequest) (apiCredentials, error) {
+	if cfg.usesAmbientCredentials() {
+		if !ch.AllowAmbientCredentials {
+			return apiCredentials{}, fmt.Errorf("the issuer has no credentials and cert-manager doesn't allow it to use the ambient credentials of the webhook, see the cert-manager flags --cluster-issuer-ambient-credentials and --issuer-ambient-credentials")
+		}
+
+		apiKey, apiSecret, err := c.ambient.Get()
+		if err != nil {
+			return apiCredentials{}, err
+		}
+
+		klog.V(4).Infof("GoDaddy use ambient key %s", fingerprint(apiKey))
+
+		return apiCredentials{APIKey: apiKey, APISecret: apiSecret}, nil
+	}
+
+	if cfg.CredentialsSecretRef != nil {
+		return c.getCredentialsBlob(cfg, ch.ResourceNamespace)
+	}
 
-	client := http.Client{
-		Timeout: 30 * time.Second,
+	apiKey, apiSecret, err := c.getAPIKey(cfg, ch.ResourceNamespace)
+	if err != nil {
+		return apiCredentials{}, err
 	}
 
-	return client.Do(req)
+	return apiCredentials{APIKey: *apiKey, APISecret: *apiSecret}, nil
 }
 
-func (c *godaddyDNSProviderSolver) extractRecordName(fqdn, domain string) string {
-	if idx := strings.Index(fqdn, "."+domain); idx != -1 {
-		return fqdn[:idx]
+func (c *godaddyDNSProviderSolver) getSecret(namespace, secretName string) (*corev1.Secret, error) {
+	sec, err := c.secrets.Get(namespace, secretName)
+	if err != nil {
+		klog.V(4).ErrorS(err, "unable to get secret", "name", secretName, "namespace", namespace)
+		return nil, fmt.Errorf("unable to get secret `%s` in namespace %s; %v", secretName, namespace, err)
 	}
 
-	return util.UnFqdn(fqdn)
+	klog.V(4).Infof("Secret `%s` in namespace:`%s` found", secretName, namespace)
+
+	return sec, nil
 }
 
-func (c *godaddyDNSProviderSolver) getZone(fqdn string) (string, error) {
-	authZone, err := util.FindZoneByFqdn(fqdn, util.RecursiveNameservers)
+// getReferencedSecret returns the Secret referenced by the config field ref of a challenge
+// whose resources live in resourceNamespace, and where it was looked up
+func (c *godaddyDNSProviderSolver) getReferencedSecret(field, name, namespace, resourceNamespace string) (*corev1.Secret, secretLocation, error) {
+	location, err := resolveSecretNamespace(field, namespace, resourceNamespace, *clusterResourceNamespace, *secretNamespacePolicy)
 	if err != nil {
-		return "", err
+		return nil, location, err
 	}
 
-	return util.UnFqdn(authZone), nil
+	klog.V(4).Infof("try to load secret `%s` in namespace:`%s`, %s", name, location.namespace, location.reason)
+
+	sec, err := c.getSecret(location.namespace, name)
+	if err != nil {
+		return nil, location, location.explain(err)
+	}
+
+	return sec, location, nil
 }
 
-func (c *godaddyDNSProviderSolver) getAPIKey(cfg godaddyDNSProviderConfig, namespace string) (*string, *string, error) {
+// getCredential returns the entry of the Secret referenced by ref
+func (c *godaddyDNSProviderSolver) getCredential(ref credentialRef, resourceNamespace string) (string, error) {
+	sec, location, err := c.getReferencedSecret(ref.field, ref.Name, ref.Namespace, resourceNamespace)
+	if err != nil {
+		return "", err
+	}
 
-	ctx := NewContext(120)
-	defer ctx.cancel()
+	value, ok := sec.Data[ref.Key]
+	if !ok {
+		klog.V(4).Infof("%s %s not found in secret \"%s/%s\"", ref.field, ref.Key, location.namespace, sec.Name)
+		return "", location.explain(fmt.Errorf("%s: entry %s not found in secret \"%s/%s\"", ref.field, ref.Key, location.namespace, sec.Name))
+	}
 
-	if cfg.APIKeySecretRef.LocalObjectReference.Name != nil {
-		secretName := *cfg.APIKeySecretRef.LocalObjectReference.Name
+	return string(value), nil
+}
 
-		klog.V(4).Infof("try to load secret `%s` in namespace:`%s`", secretName, namespace)
+// getShopperID returns the reseller's sub-account configured inline or in a Secret
+func (c *godaddyDNSProviderSolver) getShopperID(cfg godaddyDNSProviderConfig, namespace string) (string, error) {
+	if cfg.ShopperIDSecretRef == nil {
+		return cfg.ShopperID, nil
+	}
 
-		sec, err := c.client.CoreV1().Secrets(namespace).Get(ctx.ctx, secretName, metav1.GetOptions{})
-		if err != nil {
-			klog.V(4).ErrorS(err, "unable to get secret `%s`", secretName)
-			return nil, nil, fmt.Errorf("unable to get secret `%s`; %v", secretName, err)
-		}
+	sec, location, err := c.getReferencedSecret("shopperIdSecretRef", cfg.ShopperIDSecretRef.Name, cfg.ShopperIDSecretRef.Namespace, namespace)
+	if err != nil {
+		return "", err
+	}
+
+	shopperID, ok := sec.Data[cfg.ShopperIDSecretRef.Key]
+	if !ok {
+		return "", location.explain(fmt.Errorf("shopper id %s not found in secret \"%s/%s\"", cfg.ShopperIDSecretRef.Key, location.namespace, sec.Name))
+	}
 
-		klog.V(4).Infof("Secret `%s` in namespace:`%s` found", secretName, namespace)
+	return strings.TrimSpace(string(shopperID)), nil
+}
 
-		keyBytes, ok := sec.Data[cfg.APIKeySecretRef.Key]
-		if !ok {
-			klog.V(4).Infof("key %s not found in secret \"%s/%s\"", cfg.APIKeySecretRef.Key, secretName, namespace)
-			return nil, nil, fmt.Errorf("key %s not found in secret \"%s/%s\"", cfg.APIKeySecretRef.Key, secretName, namespace)
+func (c *godaddyDNSProviderSolver) getAPIKey(cfg godaddyDNSProviderConfig, namespace string) (*string, *string, error) {
+	if keyRef, secretRef, ok := cfg.apiCredentialRefs(); ok {
+		apiKey, err := c.getCredential(keyRef, namespace)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		secretBytes, ok := sec.Data[cfg.APIKeySecretRef.Secret]
-		if !ok {
-			klog.V(4).Infof("secret %s not found in secret \"%s/%s\"", cfg.APIKeySecretRef.Secret, secretName, namespace)
-			return nil, nil, fmt.Errorf("secret %s not found in secret \"%s/%s\"", cfg.APIKeySecretRef.Secret, secretName, namespace)
+		apiSecret, err := c.getCredential(secretRef, namespace)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		apiKey := string(keyBytes)
-		apiSecret := string(secretBytes)
-
-		klog.V(4).Infof("GoDaddy use key pair %s:%s", apiKey, apiSecret)
+		klog.V(4).Infof("GoDaddy use key %s from secret \"%s\"", fingerprint(apiKey), keyRef.Name)
 
 		return &apiKey, &apiSecret, nil
 	}
 
-	klog.V(4).Infof("GoDaddy use key pair %s:%s", cfg.APIKeySecretRef.Key, cfg.APIKeySecretRef.Secret)
+	if !*allowInlineCredentials {
+		return nil, nil, fmt.Errorf("apiKeySecretRef has no name, the inline key and secret are refused: store them in a Secret referenced by apiKeyRef and apiSecretRef, or start the webhook with --allow-inline-credentials")
+	}
+
+	inlineCredentialsTotal.Inc()
+
+	klog.Warningf("Inline credentials in apiKeySecretRef are deprecated and readable by anyone who can read the issuer, move the key %s to a Secret", fingerprint(cfg.APIKeySecretRef.Key))
 
 	return &cfg.APIKeySecretRef.Key, &cfg.APIKeySecretRef.Secret, nil
 }
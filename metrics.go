@@ -0,0 +1,45 @@
+package main
+
+import (
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const metricsNamespace = "godaddy_webhook"
+
+// The metrics are served by the webhook server on the /metrics endpoint
+var (
+	recordLockWaitDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      metricsNamespace,
+			Name:           "record_lock_wait_duration_seconds",
+			Help:           "Time spent waiting for a record set lock, by scope (process or lease) and result.",
+			Buckets:        []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 15, 30, 60, 120},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"scope", "result"},
+	)
+
+	secretCacheRequestsTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metricsNamespace,
+			Name:           "secret_cache_requests_total",
+			Help:           "Number of Secret lookups, by result (hit when served by the informer cache, miss otherwise).",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+
+	inlineCredentialsTotal = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Namespace:      metricsNamespace,
+			Name:           "inline_credentials_total",
+			Help:           "Number of challenges solved with the deprecated inline credentials of apiKeySecretRef.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+)
+
+func init() {
+	legacyregistry.MustRegister(recordLockWaitDuration, secretCacheRequestsTotal, inlineCredentialsTotal)
+}
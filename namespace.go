@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+)
+
+const (
+	// secretNamespacePolicySame forbids to reference a Secret outside of the issuer namespace
+	secretNamespacePolicySame = "same"
+	// secretNamespacePolicyCluster allows the ClusterIssuers only to reference a Secret of any namespace
+	secretNamespacePolicyCluster = "cluster"
+	// secretNamespacePolicyAny allows every issuer to reference a Secret of any namespace
+	secretNamespacePolicyAny = "any"
+)
+
+// secretLocation the namespace of a referenced Secret and how it was chosen
+type secretLocation struct {
+	namespace string
+	reason    string
+}
+
+// explain adds to err how the namespace of the Secret was chosen
+func (l secretLocation) explain(err error) error {
+	return fmt.Errorf("%v; namespace %s is %s", err, l.namespace, l.reason)
+}
+
+func validateSecretNamespacePolicy(policy string) error {
+	switch policy {
+	case secretNamespacePolicySame, secretNamespacePolicyCluster, secretNamespacePolicyAny:
+		return nil
+	default:
+		return fmt.Errorf("invalid --secret-namespace-policy: %s, expected %s, %s or %s", policy, secretNamespacePolicySame, secretNamespacePolicyCluster, secretNamespacePolicyAny)
+	}
+}
+
+// resolveSecretNamespace returns the namespace of the Secret referenced by the config field,
+// whose explicit namespace may be empty, for a challenge whose resources live in resourceNamespace.
+// cert-manager sets resourceNamespace to the issuer namespace for an Issuer and to its
+// cluster resource namespace for a ClusterIssuer, the latter is told by clusterNamespace.
+func resolveSecretNamespace(field, namespace, resourceNamespace, clusterNamespace, policy string) (secretLocation, error) {
+	fromCluster := resourceNamespace == clusterNamespace
+
+	if namespace == "" || namespace == resourceNamespace {
+		if fromCluster {
+			return secretLocation{
+				namespace: resourceNamespace,
+				reason:    "the cluster resource namespace used by cert-manager for a ClusterIssuer (--cluster-resource-namespace), set " + field + ".namespace to read the Secret elsewhere",
+			}, nil
+		}
+
+		return secretLocation{
+			namespace: resourceNamespace,
+			reason:    "the namespace of the Issuer",
+		}, nil
+	}
+
+	location := secretLocation{
+		namespace: namespace,
+		reason:    "set by " + field + ".namespace",
+	}
+
+	switch policy {
+	case secretNamespacePolicyAny:
+		return location, nil
+	case secretNamespacePolicyCluster:
+		if fromCluster {
+			return location, nil
+		}
+
+		return location, fmt.Errorf("%s.namespace %s differs from the namespace %s of the Issuer, only a ClusterIssuer may reference another namespace with --secret-namespace-policy=%s", field, namespace, resourceNamespace, policy)
+	default:
+		return location, fmt.Errorf("%s.namespace %s differs from the namespace %s of the issuer, which is forbidden with --secret-namespace-policy=%s", field, namespace, resourceNamespace, policy)
+	}
+}
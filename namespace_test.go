@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResolveSecretNamespace(t *testing.T) {
+	tests := []struct {
+		name              string
+		namespace         string
+		resourceNamespace string
+		policy            string
+		expected          string
+		fails             bool
+	}{
+		{name: "issuer", resourceNamespace: "team-a", policy: secretNamespacePolicyCluster, expected: "team-a"},
+		{name: "cluster issuer", resourceNamespace: "cert-manager", policy: secretNamespacePolicyCluster, expected: "cert-manager"},
+		{name: "explicit same namespace", namespace: "team-a", resourceNamespace: "team-a", policy: secretNamespacePolicySame, expected: "team-a"},
+		{name: "cluster issuer cross namespace", namespace: "dns", resourceNamespace: "cert-manager", policy: secretNamespacePolicyCluster, expected: "dns"},
+		{name: "issuer cross namespace", namespace: "dns", resourceNamespace: "team-a", policy: secretNamespacePolicyCluster, fails: true},
+		{name: "issuer cross namespace allowed", namespace: "dns", resourceNamespace: "team-a", policy: secretNamespacePolicyAny, expected: "dns"},
+		{name: "cluster issuer cross namespace forbidden", namespace: "dns", resourceNamespace: "cert-manager", policy: secretNamespacePolicySame, fails: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			location, err := resolveSecretNamespace("apiKeySecretRef", test.namespace, test.resourceNamespace, "cert-manager", test.policy)
+			if test.fails {
+				if err == nil {
+					t.Fatalf("expected an error, got namespace: %s", location.namespace)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if location.namespace != test.expected {
+				t.Fatalf("expected namespace %s, got: %s", test.expected, location.namespace)
+			}
+		})
+	}
+}
+
+func TestClusterIssuerSecretNamespace(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	sec := newTestSecret("dns", "godaddy-api-key", "key")
+	sec.Data["secret"] = []byte("secret")
+
+	solver := &godaddyDNSProviderSolver{
+		secrets: newSecretCache(fake.NewSimpleClientset(sec), nil, stopCh),
+	}
+
+	name := "godaddy-api-key"
+	cfg := godaddyDNSProviderConfig{
+		APIKeySecretRef: SecretKeySelector{
+			LocalObjectReference: LocalObjectReference{Name: &name},
+			Key:                  "key",
+			Secret:               "secret",
+		},
+	}
+
+	_, _, err := solver.getAPIKey(cfg, *clusterResourceNamespace)
+	if err == nil || !strings.Contains(err.Error(), "--cluster-resource-namespace") {
+		t.Fatalf("expected an error explaining the namespace, got: %v", err)
+	}
+
+	cfg.APIKeySecretRef.Namespace = "dns"
+
+	apiKey, apiSecret, err := solver.getAPIKey(cfg, *clusterResourceNamespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *apiKey != "key" || *apiSecret != "secret" {
+		t.Fatalf("unexpected credentials read from namespace dns")
+	}
+}
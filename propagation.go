@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/issuer/acme/dns/util"
+	"github.com/miekg/dns"
+	"k8s.io/klog/v2"
+)
+
+// authoritativePort is the port queried on the authoritative nameservers, replaced in tests
+var authoritativePort = "53"
+
+// waitForPropagation polls the authoritative nameservers of zone every interval
+// until all of them serve the TXT value at fqdn, or ctx is done.
+func waitForPropagation(ctx context.Context, zone, fqdn, value string, interval time.Duration) error {
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		pending, err := checkPropagation(ctx, zone, fqdn, value)
+
+		switch {
+		case err != nil:
+			klog.Warningf("Propagation check %d of %s on zone %s failed: %v", attempt, fqdn, zone, err)
+		case len(pending) == 0:
+			klog.Infof("Record %s propagated on the nameservers of zone %s after %s", fqdn, zone, time.Since(start).Round(time.Second))
+			return nil
+		default:
+			klog.Infof("Propagation check %d, record %s not yet served by: %s", attempt, fqdn, strings.Join(pending, ", "))
+		}
+
+		timer := time.NewTimer(interval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			if err == nil {
+				err = fmt.Errorf("not served by %s", strings.Join(pending, ", "))
+			}
+
+			return fmt.Errorf("record %s not propagated on the nameservers of zone %s after %s: %v", fqdn, zone, time.Since(start).Round(time.Second), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// checkPropagation returns the authoritative nameservers of zone not serving
+// the TXT value at fqdn yet. It gives up when ctx is done.
+func checkPropagation(ctx context.Context, zone, fqdn, value string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	nameservers, err := authoritativeNameservers(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []string
+
+	for _, nameserver := range nameservers {
+		found, err := serveTXT(ctx, nameserver, fqdn, value)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if err != nil {
+			klog.V(2).Infof("Unable to query %s on %s: %v", fqdn, nameserver, err)
+		}
+
+		if !found {
+			pending = append(pending, nameserver)
+		}
+	}
+
+	return pending, nil
+}
+
+// authoritativeNameservers returns the addresses (host:port) of the nameservers of zone
+func authoritativeNameservers(ctx context.Context, zone string) ([]string, error) {
+	msg, err := util.DNSQuery(util.ToFqdn(zone), dns.TypeNS, recursiveNameservers, true)
+	if err != nil {
+		return nil, fmt.Errorf("unable to lookup the nameservers of zone %s: %v", zone, err)
+	}
+
+	var nameservers []string
+
+	for _, rr := range msg.Answer {
+		ns, ok := rr.(*dns.NS)
+		if !ok {
+			continue
+		}
+
+		// Query the nameserver by address when it can be resolved, else let the dialer resolve it
+		host := lookupAddress(ctx, ns.Ns)
+
+		if host == "" {
+			host = util.UnFqdn(ns.Ns)
+		}
+
+		nameservers = append(nameservers, net.JoinHostPort(host, authoritativePort))
+	}
+
+	if len(nameservers) == 0 {
+		return nil, fmt.Errorf("no nameserver found for zone %s", zone)
+	}
+
+	return nameservers, nil
+}
+
+// lookupAddress returns the first IPv4 address of host on the recursive nameservers, empty if none
+func lookupAddress(ctx context.Context, host string) string {
+	m := new(dns.Msg)
+	m.SetQuestion(util.ToFqdn(host), dns.TypeA)
+
+	client := &dns.Client{Net: "udp", Timeout: util.DNSTimeout}
+
+	for _, nameserver := range recursiveNameservers {
+		in, _, err := client.ExchangeContext(ctx, m, nameserver)
+		if err != nil {
+			continue
+		}
+
+		for _, rr := range in.Answer {
+			if a, ok := rr.(*dns.A); ok {
+				return a.A.String()
+			}
+		}
+	}
+
+	return ""
+}
+
+// serveTXT returns true if nameserver answers the TXT value at fqdn, a truncated answer is queried again over TCP
+func serveTXT(ctx context.Context, nameserver, fqdn, value string) (bool, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(util.ToFqdn(fqdn), dns.TypeTXT)
+	m.RecursionDesired = false
+
+	msg, _, err := (&dns.Client{Net: "udp", Timeout: util.DNSTimeout}).ExchangeContext(ctx, m, nameserver)
+	if err == nil && msg.Truncated {
+		msg, _, err = (&dns.Client{Net: "tcp", Timeout: util.DNSTimeout}).ExchangeContext(ctx, m, nameserver)
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, rr := range msg.Answer {
+		if txt, ok := rr.(*dns.TXT); ok && strings.Join(txt.Txt, "") == value {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
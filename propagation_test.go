@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newPropagationDNS(t *testing.T) *fakeDNS {
+	server := newFakeDNS(t)
+	server.add(t,
+		"example.com. 300 IN NS ns1.example.com.",
+		"example.com. 300 IN NS ns2.example.com.",
+		"ns1.example.com. 300 IN A 127.0.0.1",
+		"ns2.example.com. 300 IN A 127.0.0.1",
+	)
+
+	_, port, err := net.SplitHostPort(server.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nameservers, authoritative := recursiveNameservers, authoritativePort
+
+	t.Cleanup(func() {
+		recursiveNameservers, authoritativePort = nameservers, authoritative
+	})
+
+	recursiveNameservers = []string{server.Addr}
+	authoritativePort = port
+
+	return server
+}
+
+func TestWaitForPropagation(t *testing.T) {
+	server := newPropagationDNS(t)
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		server.add(t, `_acme-challenge.example.com. 600 IN TXT "other-key"`, `_acme-challenge.example.com. 600 IN TXT "key"`)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := waitForPropagation(ctx, "example.com", "_acme-challenge.example.com.", "key", 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForPropagationTimeout(t *testing.T) {
+	server := newPropagationDNS(t)
+	server.add(t, `_acme-challenge.example.com. 600 IN TXT "other-key"`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	if err := waitForPropagation(ctx, "example.com", "_acme-challenge.example.com.", "key", 50*time.Millisecond); err == nil {
+		t.Fatal("expected a timeout")
+	}
+
+	if pending, err := checkPropagation(context.Background(), "example.com", "_acme-challenge.example.com.", "key"); err != nil || len(pending) != 2 {
+		t.Fatalf("expected 2 pending nameservers, got: %v, error: %v", pending, err)
+	}
+}
+
+func TestWaitForPropagationBoundsPresent(t *testing.T) {
+	server := newPropagationDNS(t)
+	server.add(t, `_acme-challenge.example.com. 600 IN TXT "other-key"`)
+
+	solver := &godaddyDNSProviderSolver{}
+	cfg := godaddyDNSProviderConfig{
+		PropagationTimeout:  &metav1.Duration{Duration: time.Second},
+		PropagationInterval: &metav1.Duration{Duration: 50 * time.Millisecond},
+	}
+
+	// Present started long ago, its budget is already spent
+	start := time.Now()
+
+	if err := solver.waitForPropagation(context.Background(), cfg, start.Add(-time.Second), "example.com", "_acme-challenge", "key"); err == nil {
+		t.Fatal("expected a timeout")
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected the wait to stop at the deadline of Present, elapsed: %s", elapsed)
+	}
+
+	server.mu.Lock()
+	queries := server.queries
+	server.mu.Unlock()
+
+	if queries != 0 {
+		t.Fatalf("expected no query once the deadline is passed, got: %d", queries)
+	}
+}
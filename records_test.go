@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Fred78290/cert-manager-webhook-godaddy/godaddy"
+)
+
+func TestPresentKeepsExistingValues(t *testing.T) {
+	stubSolver(t, staticZone("example.com."))
+
+	api := newFakeGoDaddy(t)
+
+	other := godaddy.DNSRecord{Type: "TXT", Name: "_acme-challenge", Data: "other-key", TTL: 3600}
+	api.set("example.com", "TXT", "_acme-challenge", other)
+
+	solver := &godaddyDNSProviderSolver{}
+	config := fmt.Sprintf(`{"apiKeySecretRef":{"key":"key","secret":"secret"},"ttl":600,"apiURL":%q}`, api.URL)
+
+	if err := solver.Present(newChallengeRequest("_acme-challenge.example.com.", "example.com.", "key", config)); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []godaddy.DNSRecord{other, {Type: "TXT", Name: "_acme-challenge", Data: "key", TTL: 600}}
+
+	if records := api.get("example.com", "TXT", "_acme-challenge"); !reflect.DeepEqual(records, expected) {
+		t.Fatalf("expected the existing value to be kept with its TTL, got: %+v", records)
+	}
+}
+
+func TestCleanUpKeepsOtherValues(t *testing.T) {
+	stubSolver(t, staticZone("example.com."))
+
+	minBackoff := *apiMinBackoff
+
+	t.Cleanup(func() {
+		*apiMinBackoff = minBackoff
+	})
+
+	*apiMinBackoff = time.Millisecond
+
+	api := newFakeGoDaddy(t)
+
+	other := godaddy.DNSRecord{Type: "TXT", Name: "_acme-challenge", Data: "other-key", TTL: 3600}
+	api.set("example.com", "TXT", "_acme-challenge", other, godaddy.DNSRecord{Type: "TXT", Name: "_acme-challenge", Data: "key", TTL: 600})
+
+	solver := &godaddyDNSProviderSolver{}
+	config := fmt.Sprintf(`{"apiKeySecretRef":{"key":"key","secret":"secret"},"ttl":600,"apiURL":%q}`, api.URL)
+
+	if err := solver.CleanUp(newChallengeRequest("_acme-challenge.example.com.", "example.com.", "key", config)); err != nil {
+		t.Fatal(err)
+	}
+
+	if records := api.get("example.com", "TXT", "_acme-challenge"); !reflect.DeepEqual(records, []godaddy.DNSRecord{other}) {
+		t.Fatalf("expected the other value to be kept with its TTL, got: %+v", records)
+	}
+
+	if methods := api.methods(); !reflect.DeepEqual(methods, []string{"GET", "PUT"}) {
+		t.Fatalf("expected the other value to be written back, got: %v", methods)
+	}
+
+	// The response of the DELETE is lost, its retry finds the record set deleted
+	api.mu.Lock()
+	api.lostDeletes = 1
+	api.mu.Unlock()
+
+	if err := solver.CleanUp(newChallengeRequest("_acme-challenge.example.com.", "example.com.", "other-key", config)); err != nil {
+		t.Fatal(err)
+	}
+
+	if records := api.get("example.com", "TXT", "_acme-challenge"); len(records) != 0 {
+		t.Fatalf("expected the record set to be deleted, got: %+v", records)
+	}
+
+	if methods := api.methods(); !reflect.DeepEqual(methods, []string{"GET", "DELETE", "DELETE"}) {
+		t.Fatalf("expected the empty record set to be deleted, got: %v", methods)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Fred78290/cert-manager-webhook-godaddy/godaddy"
+)
+
+const redacted = "<redacted>"
+
+// fingerprint returns a printable hash of a credential, empty if the credential is empty
+func fingerprint(credential string) string {
+	if credential == "" {
+		return ""
+	}
+
+	return "sha256:" + godaddy.Fingerprint(credential)
+}
+
+// redact masks a credential, an empty credential is left empty
+func redact(credential string) string {
+	if credential == "" {
+		return ""
+	}
+
+	return redacted
+}
+
+// String prints the reference, the inline credentials are masked
+func (s SecretKeySelector) String() string {
+	if s.Name == nil {
+		return fmt.Sprintf("{key:%s secret:%s}", fingerprint(s.Key), redact(s.Secret))
+	}
+
+	return fmt.Sprintf("{name:%s namespace:%s key:%s secret:%s}", *s.Name, s.Namespace, s.Key, s.Secret)
+}
+
+// GoString is used by the %#v verb, it masks the inline credentials too
+func (s SecretKeySelector) GoString() string {
+	return s.String()
+}
+
+// MarshalLog is used by the structured loggers, see logr.Marshaler
+func (s SecretKeySelector) MarshalLog() interface{} {
+	return s.String()
+}
+
+// String prints the reference, it holds no credential
+func (s SecretKeyRef) String() string {
+	return fmt.Sprintf("{name:%s namespace:%s key:%s}", s.Name, s.Namespace, s.Key)
+}
+
+// String prints the account of the credentials, the key and secret are masked
+func (c apiCredentials) String() string {
+	return fmt.Sprintf("{key:%s secret:%s shopperId:%s}", fingerprint(c.APIKey), redact(c.APISecret), c.ShopperID)
+}
+
+// GoString is used by the %#v verb, it masks the credentials too
+func (c apiCredentials) GoString() string {
+	return c.String()
+}
+
+// printableConfig has the fields of godaddyDNSProviderConfig without its methods
+type printableConfig godaddyDNSProviderConfig
+
+// String prints the config, the credentials are masked by SecretKeySelector.String
+func (c godaddyDNSProviderConfig) String() string {
+	return fmt.Sprintf("%+v", printableConfig(c))
+}
+
+// GoString is used by the %#v verb, it masks the credentials too
+func (c godaddyDNSProviderConfig) GoString() string {
+	return c.String()
+}
+
+// MarshalLog is used by the structured loggers, see logr.Marshaler
+func (c godaddyDNSProviderConfig) MarshalLog() interface{} {
+	return c.String()
+}
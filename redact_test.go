@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/klog/v2"
+)
+
+// syncBuffer a bytes.Buffer safe for the concurrent writes of klog
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+// captureLogs redirects klog to a buffer at the highest verbosity until the end of the test
+func captureLogs(t *testing.T) *syncBuffer {
+	output := &syncBuffer{}
+	flags := flag.NewFlagSet("klog", flag.ContinueOnError)
+
+	klog.InitFlags(flags)
+
+	_ = flags.Set("v", "10")
+	_ = flags.Set("logtostderr", "false")
+	_ = flags.Set("alsologtostderr", "false")
+
+	klog.SetOutput(output)
+
+	t.Cleanup(func() {
+		klog.Flush()
+
+		_ = flags.Set("v", "0")
+		_ = flags.Set("logtostderr", "true")
+	})
+
+	return output
+}
+
+func TestRedactConfig(t *testing.T) {
+	name := "godaddy-api-key"
+	inline := godaddyDNSProviderConfig{APIKeySecretRef: SecretKeySelector{Key: "raw-key", Secret: "raw-secret"}}
+	ref := godaddyDNSProviderConfig{APIKeySecretRef: SecretKeySelector{LocalObjectReference: LocalObjectReference{Name: &name}, Key: "key", Secret: "secret"}}
+
+	for _, cfg := range []godaddyDNSProviderConfig{inline, ref} {
+		logged, _ := json.Marshal(cfg.MarshalLog())
+
+		for _, printed := range []string{fmt.Sprintf("%v", cfg), fmt.Sprintf("%+v", cfg), fmt.Sprintf("%#v", cfg), fmt.Sprint(&cfg), string(logged)} {
+			if strings.Contains(printed, "raw-key") || strings.Contains(printed, "raw-secret") {
+				t.Fatalf("credentials printed: %s", printed)
+			}
+		}
+	}
+
+	if printed := ref.String(); !strings.Contains(printed, "name:godaddy-api-key") {
+		t.Fatalf("expected the secret reference, got: %s", printed)
+	}
+
+	if printed := inline.String(); !strings.Contains(printed, fingerprint("raw-key")) {
+		t.Fatalf("expected the key fingerprint, got: %s", printed)
+	}
+}
+
+func TestCredentialsNeverLogged(t *testing.T) {
+	const apiKey, apiSecret = "log-leak-key", "log-leak-secret"
+
+	output := captureLogs(t)
+
+	api := newFakeGoDaddy(t)
+
+	stubSolver(t, staticZone("example.com."))
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	sec := newTestSecret("default", "godaddy-api-key", apiKey)
+	sec.Data["secret"] = []byte(apiSecret)
+
+	solver := &godaddyDNSProviderSolver{
+		secrets: newSecretCache(fake.NewSimpleClientset(sec), nil, stopCh),
+	}
+
+	configs := []string{
+		fmt.Sprintf(`{"apiKeySecretRef":{"key":%q,"secret":%q},"apiURL":%q}`, apiKey, apiSecret, api.URL),
+		fmt.Sprintf(`{"apiKeySecretRef":{"name":"godaddy-api-key","key":"key","secret":"secret"},"apiURL":%q}`, api.URL),
+		// A missing entry must not disclose the other ones
+		fmt.Sprintf(`{"apiKeySecretRef":{"name":"godaddy-api-key","key":"key","secret":"missing"},"apiURL":%q}`, api.URL),
+	}
+
+	for _, config := range configs {
+		ch := newChallengeRequest("_acme-challenge.example.com.", "example.com.", "token", config)
+
+		if err := solver.Present(ch); err == nil {
+			_ = solver.CleanUp(ch)
+		} else if strings.Contains(err.Error(), apiKey) || strings.Contains(err.Error(), apiSecret) {
+			t.Fatalf("credentials in error: %v", err)
+		}
+	}
+
+	klog.Flush()
+
+	logged := output.String()
+
+	if !strings.Contains(logged, "Present record") {
+		t.Fatalf("logs not captured: %s", logged)
+	}
+
+	if strings.Contains(logged, apiKey) || strings.Contains(logged, apiSecret) {
+		t.Fatalf("credentials in logs:\n%s", logged)
+	}
+}
@@ -26,9 +26,13 @@ EOF
 
 cat > _test/kubebuilder/godaddy/config.json <<EOF
 {
-  "apiKeySecretRef": {
-    "key": "$GODADDY_API_KEY",
-    "secret": "$GODADDY_API_SECRET"
+  "apiKeyRef": {
+    "name": "godaddy-api-key",
+    "key": "key"
+  },
+  "apiSecretRef": {
+    "name": "godaddy-api-key",
+    "key": "secret"
   },
   "production": true,
   "ttl": 600
@@ -44,9 +44,13 @@ EOF
 
 cat > $TEST_MANIFEST_PATH/config.json <<EOF
 {
-  "apiKeySecretRef": {
-    "key": "$GODADDY_API_KEY",
-    "secret": "$GODADDY_API_SECRET"
+  "apiKeyRef": {
+    "name": "godaddy-api-key",
+    "key": "key"
+  },
+  "apiSecretRef": {
+    "name": "godaddy-api-key",
+    "key": "secret"
   },
   "production": true,
   "ttl": 600
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// secretInformer the lister of one Secret, the watch is scoped to its name
+type secretInformer struct {
+	lister    corelisters.SecretLister
+	hasSynced cache.InformerSynced
+	stop      chan struct{}
+}
+
+// secretCache serves the referenced Secrets from memory. An informer watching only
+// the referenced Secret is started on its first lookup, the rotated Secret is applied
+// by its watch. A Secret whose informer can't sync, e.g. when the webhook may only get
+// Secrets, is read from the API server until the next attempt after resync.
+type secretCache struct {
+	client      kubernetes.Interface
+	namespaces  map[string]bool
+	resync      time.Duration
+	syncTimeout time.Duration
+	timeout     time.Duration
+	stopCh      <-chan struct{}
+	mu          sync.Mutex
+	informers   map[string]*secretInformer
+	uncached    map[string]time.Time
+}
+
+// newSecretCache returns a cache of the Secrets of namespaces, any namespace when empty
+func newSecretCache(client kubernetes.Interface, namespaces []string, stopCh <-chan struct{}) *secretCache {
+	s := &secretCache{
+		client:      client,
+		namespaces:  map[string]bool{},
+		resync:      10 * time.Minute,
+		syncTimeout: 5 * time.Second,
+		timeout:     30 * time.Second,
+		stopCh:      stopCh,
+		informers:   map[string]*secretInformer{},
+		uncached:    map[string]time.Time{},
+	}
+
+	for _, namespace := range namespaces {
+		s.namespaces[namespace] = true
+	}
+
+	return s
+}
+
+// parseNamespaces splits a comma separated list of namespaces
+func parseNamespaces(value string) []string {
+	var namespaces []string
+
+	for _, namespace := range strings.Split(value, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	return namespaces
+}
+
+// Get returns the Secret name of namespace. A Secret unknown to its informer,
+// e.g. created a moment ago, or not cached is read from the API server.
+func (s *secretCache) Get(namespace, name string) (*corev1.Secret, error) {
+	if len(s.namespaces) > 0 && !s.namespaces[namespace] {
+		return nil, fmt.Errorf("namespace %s is not watched, the webhook only reads Secrets in: %s (see --secret-namespaces)", namespace, strings.Join(s.watchedNamespaces(), ", "))
+	}
+
+	if informer := s.informer(namespace, name); informer != nil {
+		sec, err := informer.lister.Secrets(namespace).Get(name)
+		if err == nil {
+			secretCacheRequestsTotal.WithLabelValues("hit").Inc()
+
+			return sec, nil
+		}
+
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+
+		klog.V(4).Infof("Secret `%s` in namespace:`%s` not cached, read it from the API server", name, namespace)
+	}
+
+	secretCacheRequestsTotal.WithLabelValues("miss").Inc()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	return s.client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+// informer returns the synced informer of the Secret, started if needed.
+// It returns nil when the Secret is read from the API server.
+func (s *secretCache) informer(namespace, name string) *secretInformer {
+	key := namespace + "/" + name
+
+	s.mu.Lock()
+
+	if retry, found := s.uncached[key]; found && time.Now().Before(retry) {
+		s.mu.Unlock()
+
+		return nil
+	}
+
+	informer := s.informerLocked(namespace, name)
+
+	s.mu.Unlock()
+
+	if informer.hasSynced() {
+		return informer
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.syncTimeout)
+	defer cancel()
+
+	if cache.WaitForCacheSync(ctx.Done(), informer.hasSynced) {
+		return informer
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.informers[key] == informer {
+		klog.Warningf("Unable to cache the Secret `%s` in namespace:`%s`, read it from the API server, check the webhook can list and watch it", name, namespace)
+
+		close(informer.stop)
+		delete(s.informers, key)
+
+		s.uncached[key] = time.Now().Add(s.resync)
+	}
+
+	return nil
+}
+
+func (s *secretCache) informerLocked(namespace, name string) *secretInformer {
+	key := namespace + "/" + name
+
+	if informer, ok := s.informers[key]; ok {
+		return informer
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(s.client, s.resync,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		}))
+
+	secrets := factory.Core().V1().Secrets()
+
+	informer := &secretInformer{
+		lister:    secrets.Lister(),
+		hasSynced: secrets.Informer().HasSynced,
+		stop:      make(chan struct{}),
+	}
+
+	stopCh := make(chan struct{})
+
+	// The informer stops with the webhook or once given up
+	go func() {
+		defer close(stopCh)
+
+		select {
+		case <-s.stopCh:
+		case <-informer.stop:
+		}
+	}()
+
+	factory.Start(stopCh)
+
+	s.informers[key] = informer
+	delete(s.uncached, key)
+
+	klog.Infof("Watch the Secret `%s` in namespace:`%s`", name, namespace)
+
+	return informer
+}
+
+func (s *secretCache) watchedNamespaces() []string {
+	namespaces := make([]string, 0, len(s.namespaces))
+
+	for namespace := range s.namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+
+	sort.Strings(namespaces)
+
+	return namespaces
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func newTestSecret(namespace, name, key string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Data:       map[string][]byte{"key": []byte(key)},
+	}
+}
+
+func secretCacheRequests(t *testing.T, result string) float64 {
+	value, err := testutil.GetCounterMetricValue(secretCacheRequestsTotal.WithLabelValues(result))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return value
+}
+
+func TestSecretCacheRotation(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	client := fake.NewSimpleClientset(newTestSecret("cert-manager", "godaddy-api-key", "old"))
+	secrets := newSecretCache(client, nil, stopCh)
+
+	hits := secretCacheRequests(t, "hit")
+
+	sec, err := secrets.Get("cert-manager", "godaddy-api-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(sec.Data["key"]) != "old" {
+		t.Fatalf("unexpected key: %s", sec.Data["key"])
+	}
+
+	if got := secretCacheRequests(t, "hit"); got != hits+1 {
+		t.Fatalf("expected a cache hit, got %v hits", got-hits)
+	}
+
+	if _, err := client.CoreV1().Secrets("cert-manager").Update(context.Background(), newTestSecret("cert-manager", "godaddy-api-key", "new"), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for string(sec.Data["key"]) != "new" {
+		if time.Now().After(deadline) {
+			t.Fatal("the rotated secret was not picked up")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+
+		if sec, err = secrets.Get("cert-manager", "godaddy-api-key"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSecretCacheMiss(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	client := fake.NewSimpleClientset()
+	secrets := newSecretCache(client, nil, stopCh)
+
+	misses := secretCacheRequests(t, "miss")
+
+	if _, err := secrets.Get("cert-manager", "missing"); err == nil {
+		t.Fatal("expected an error on a missing secret")
+	}
+
+	if got := secretCacheRequests(t, "miss"); got != misses+1 {
+		t.Fatalf("expected a cache miss, got %v misses", got-misses)
+	}
+}
+
+func TestSecretCacheNamespaces(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	client := fake.NewSimpleClientset(newTestSecret("team-a", "godaddy-api-key", "a"), newTestSecret("team-b", "godaddy-api-key", "b"))
+	secrets := newSecretCache(client, parseNamespaces(" team-a, ,cert-manager"), stopCh)
+
+	if _, err := secrets.Get("team-a", "godaddy-api-key"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := secrets.Get("team-b", "godaddy-api-key")
+	if err == nil || !strings.Contains(err.Error(), "cert-manager, team-a") {
+		t.Fatalf("expected an error listing the watched namespaces, got: %v", err)
+	}
+}
+
+func TestSecretCacheScopedToReferencedSecret(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	client := fake.NewSimpleClientset(newTestSecret("cert-manager", "godaddy-api-key", "key"), newTestSecret("cert-manager", "tls", "cert"))
+
+	var mu sync.Mutex
+	var selectors []string
+
+	client.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		selectors = append(selectors, action.(k8stesting.ListAction).GetListRestrictions().Fields.String())
+
+		return false, nil, nil
+	})
+
+	if _, err := newSecretCache(client, nil, stopCh).Get("cert-manager", "godaddy-api-key"); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(selectors) == 0 || selectors[0] != "metadata.name=godaddy-api-key" {
+		t.Fatalf("expected the Secrets to be listed by name, got: %v", selectors)
+	}
+}
+
+func TestSecretCacheFallback(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	client := fake.NewSimpleClientset(newTestSecret("cert-manager", "godaddy-api-key", "key"))
+
+	// The webhook is only allowed to get the Secrets
+	client.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(corev1.Resource("secrets"), "", fmt.Errorf("list is not allowed"))
+	})
+
+	secrets := newSecretCache(client, nil, stopCh)
+	secrets.syncTimeout = 100 * time.Millisecond
+
+	misses := secretCacheRequests(t, "miss")
+
+	for i := 0; i < 2; i++ {
+		sec, err := secrets.Get("cert-manager", "godaddy-api-key")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(sec.Data["key"]) != "key" {
+			t.Fatalf("unexpected key: %s", sec.Data["key"])
+		}
+	}
+
+	if got := secretCacheRequests(t, "miss"); got != misses+2 {
+		t.Fatalf("expected the secret to be read from the API server, got %v misses", got-misses)
+	}
+
+	if len(secrets.informers) != 0 {
+		t.Fatalf("expected the informer to be stopped, got: %d", len(secrets.informers))
+	}
+}
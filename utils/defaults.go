@@ -27,6 +27,22 @@ const (
 	DefaultLeaderElectionRenewDeadline = 40 * time.Second
 	DefaultLeaderElectionRetryPeriod   = 15 * time.Second
 
+	// A waiter must outlive a lease left by a dead replica: the lock timeout
+	// is longer than the lease duration plus the retry period
+	DefaultRecordLockTimeout        = 30 * time.Second
+	DefaultRecordLeaseDuration      = 15 * time.Second
+	DefaultRecordLeaseRenewDeadline = 10 * time.Second
+	DefaultRecordLeaseRetryPeriod   = 2 * time.Second
+
+	DefaultDomainCacheTTL  = 10 * time.Minute
+	DefaultAccountCacheTTL = time.Hour
+
+	// Below the request timeout of the kube-apiserver proxying the calls of cert-manager
+	DefaultPropagationTimeout  = 45 * time.Second
+	DefaultPropagationInterval = 10 * time.Second
+
+	DefaultClusterResourceNamespace = "cert-manager"
+
 	DefaultEnableProfiling = false
 	DefaultProfilerAddr    = "localhost:6060"
 )
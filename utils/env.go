@@ -0,0 +1,73 @@
+/*
+Copyright 2021 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// GetEnv returns the value of the environment variable key or defaultValue if it's not set.
+func GetEnv(key, defaultValue string) string {
+	if value, found := os.LookupEnv(key); found {
+		return value
+	}
+
+	return defaultValue
+}
+
+// GetEnvDuration returns the duration held by the environment variable key or defaultValue if it's not set or invalid.
+func GetEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, found := os.LookupEnv(key); found {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+
+		klog.Warningf("Ignore invalid duration %s=%q", key, value)
+	}
+
+	return defaultValue
+}
+
+// GetEnvInt returns the integer held by the environment variable key or defaultValue if it's not set or invalid.
+func GetEnvInt(key string, defaultValue int) int {
+	if value, found := os.LookupEnv(key); found {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+
+		klog.Warningf("Ignore invalid integer %s=%q", key, value)
+	}
+
+	return defaultValue
+}
+
+// GetEnvBool returns the boolean held by the environment variable key or defaultValue if it's not set or invalid.
+func GetEnvBool(key string, defaultValue bool) bool {
+	if value, found := os.LookupEnv(key); found {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+
+		klog.Warningf("Ignore invalid boolean %s=%q", key, value)
+	}
+
+	return defaultValue
+}
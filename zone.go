@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	"github.com/cert-manager/cert-manager/pkg/issuer/acme/dns/util"
+	"k8s.io/klog/v2"
+
+	"github.com/Fred78290/cert-manager-webhook-godaddy/godaddy"
+)
+
+// Zone resolution strategies, see godaddyDNSProviderConfig.ZoneResolution
+const (
+	// zoneResolutionDNS finds the zone with a SOA lookup on the recursive nameservers
+	zoneResolutionDNS = "dns"
+	// zoneResolutionAPI picks the longest domain of the GoDaddy account matching the fqdn
+	zoneResolutionAPI = "api"
+	// zoneResolutionCertManager trusts the zone resolved by cert-manager
+	zoneResolutionCertManager = "certManager"
+	// zoneResolutionStatic uses the zones set in the configuration
+	zoneResolutionStatic = "static"
+)
+
+// recordTarget returns the fqdn of the TXT record of the challenge and the zone resolved
+// by cert-manager, empty when unknown. With cfg.FollowCNAME, the record is the final
+// target of the CNAME chain starting at the challenge fqdn.
+func recordTarget(cfg godaddyDNSProviderConfig, ch *v1alpha1.ChallengeRequest) (string, string, error) {
+	fqdn := ch.ResolvedFQDN
+
+	if !cfg.FollowCNAME {
+		return fqdn, ch.ResolvedZone, nil
+	}
+
+	target, err := followCNAME(fqdn, recursiveNameservers)
+	if err != nil {
+		return "", "", err
+	}
+
+	if strings.EqualFold(target, util.ToFqdn(fqdn)) {
+		return fqdn, ch.ResolvedZone, nil
+	}
+
+	klog.Infof("Record %s is delegated to %s", fqdn, target)
+
+	if cfg.ZoneResolution == zoneResolutionCertManager {
+		return "", "", fmt.Errorf("zone of CNAME target %s can't be resolved with zoneResolution %s", target, zoneResolutionCertManager)
+	}
+
+	return target, "", nil
+}
+
+// resolveRecord returns the zone and the relative name of the TXT record of the challenge,
+// see recordTarget.
+func (c *godaddyDNSProviderSolver) resolveRecord(ctx context.Context, cfg godaddyDNSProviderConfig, client *godaddy.Client, ch *v1alpha1.ChallengeRequest) (string, string, error) {
+	fqdn, resolvedZone, err := recordTarget(cfg, ch)
+	if err != nil {
+		return "", "", err
+	}
+
+	dnsZone, err := c.getZone(ctx, cfg, client, fqdn, resolvedZone)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to get zone of: %s, %w", fqdn, err)
+	}
+
+	recordName, err := extractRecordName(fqdn, dnsZone)
+	if err != nil {
+		return "", "", err
+	}
+
+	return dnsZone, recordName, nil
+}
+
+// getZone returns the zone hosting the record fqdn, found with the strategy
+// selected by cfg.ZoneResolution. resolvedZone is the zone found by cert-manager.
+func (c *godaddyDNSProviderSolver) getZone(ctx context.Context, cfg godaddyDNSProviderConfig, client *godaddy.Client, fqdn, resolvedZone string) (string, error) {
+	switch cfg.ZoneResolution {
+	case zoneResolutionAPI:
+		return c.findZoneByAPI(ctx, client, fqdn)
+	case zoneResolutionCertManager:
+		if resolvedZone == "" {
+			return "", fmt.Errorf("cert-manager didn't resolve the zone of: %s", fqdn)
+		}
+
+		return util.UnFqdn(resolvedZone), nil
+	case zoneResolutionStatic:
+		return findStaticZone(cfg, fqdn)
+	}
+
+	authZone, err := findZoneByFqdn(fqdn, recursiveNameservers)
+	if err != nil {
+		return "", err
+	}
+
+	return util.UnFqdn(authZone), nil
+}
+
+// findStaticZone returns the zone mapped to the longest suffix of fqdn in cfg.Zones,
+// cfg.Zone when none matches.
+func findStaticZone(cfg godaddyDNSProviderConfig, fqdn string) (string, error) {
+	name := util.UnFqdn(fqdn)
+	suffixes := make([]string, 0, len(cfg.Zones))
+
+	for suffix := range cfg.Zones {
+		suffixes = append(suffixes, util.UnFqdn(suffix))
+	}
+
+	if suffix, err := util.FindBestMatch(name, suffixes...); err == nil {
+		for key, zone := range cfg.Zones {
+			if util.UnFqdn(key) == suffix {
+				return util.UnFqdn(zone), nil
+			}
+		}
+	}
+
+	if cfg.Zone != "" {
+		return util.UnFqdn(cfg.Zone), nil
+	}
+
+	return "", fmt.Errorf("no static zone matches: %s", name)
+}
+
+// extractRecordName returns the name of the record fqdn relative to zone,
+// `@` for the apex of the zone.
+func extractRecordName(fqdn, zone string) (string, error) {
+	name := util.UnFqdn(fqdn)
+	zone = util.UnFqdn(zone)
+
+	if strings.EqualFold(name, zone) {
+		return "@", nil
+	}
+
+	if len(name) > len(zone) && strings.HasSuffix(strings.ToLower(name), "."+strings.ToLower(zone)) {
+		return name[:len(name)-len(zone)-1], nil
+	}
+
+	return "", fmt.Errorf("record %s is not in zone %s", name, zone)
+}
+
+// domainCache caches the domains managed by each GoDaddy account.
+// The zero value is ready to use.
+type domainCache struct {
+	mu      sync.Mutex
+	entries map[string]domainCacheEntry
+}
+
+type domainCacheEntry struct {
+	domains []string
+	expires time.Time
+}
+
+// get returns the domains of the client's account, fetched from the API when
+// they are not cached or the cache is older than ttl.
+func (d *domainCache) get(ctx context.Context, client *godaddy.Client, ttl time.Duration, refresh bool) ([]string, error) {
+	account := client.BaseURL() + "/" + client.Account()
+
+	d.mu.Lock()
+	entry, found := d.entries[account]
+	d.mu.Unlock()
+
+	if found && !refresh && time.Now().Before(entry.expires) {
+		return entry.domains, nil
+	}
+
+	result, err := client.ListDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	domains := make([]string, 0, len(result))
+
+	for _, domain := range result {
+		if managedDomain(domain) {
+			domains = append(domains, domain.Domain)
+		}
+	}
+
+	klog.V(2).Infof("Found %d domains for account: %s, shopper: %s", len(domains), client.Account(), client.ShopperID())
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.entries == nil {
+		d.entries = make(map[string]domainCacheEntry)
+	}
+
+	d.entries[account] = domainCacheEntry{
+		domains: domains,
+		expires: time.Now().Add(ttl),
+	}
+
+	return domains, nil
+}
+
+// managedDomain reports whether the domain is still managed by the account, not cancelled or transferred away
+func managedDomain(domain godaddy.Domain) bool {
+	status := strings.ToUpper(domain.Status)
+
+	return !strings.HasPrefix(status, "TRANSFERRED_OUT") && !strings.HasPrefix(status, "CANCELLED")
+}
+
+// findZoneByAPI returns the longest domain managed by the client's account which is
+// a suffix of fqdn. The domains are fetched again once if the cached ones don't match.
+func (c *godaddyDNSProviderSolver) findZoneByAPI(ctx context.Context, client *godaddy.Client, fqdn string) (string, error) {
+	name := util.UnFqdn(fqdn)
+
+	for _, refresh := range []bool{false, true} {
+		domains, err := c.domains.get(ctx, client, *domainCacheTTL, refresh)
+		if err != nil {
+			return "", err
+		}
+
+		if zone, err := util.FindBestMatch(name, domains...); err == nil {
+			return zone, nil
+		}
+	}
+
+	return "", fmt.Errorf("no domain of the GoDaddy account matches: %s", name)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestZoneResolutionAPI(t *testing.T) {
+	stubSolver(t, func(fqdn string, nameservers []string) (string, error) {
+		return "", fmt.Errorf("unexpected DNS lookup of: %s", fqdn)
+	})
+
+	api := newFakeGoDaddy(t)
+	api.domains = []string{"example.com", "sub.example.com", "other.com", "www.sub.example.com"}
+	// The cancelled domain must not win the longest match
+	api.statuses = map[string]string{"www.sub.example.com": "CANCELLED"}
+
+	solver := &godaddyDNSProviderSolver{}
+	config := fmt.Sprintf(`{"apiKeySecretRef":{"key":"key","secret":"secret"},"ttl":600,"apiURL":%q,"zoneResolution":"api"}`, api.URL)
+
+	for _, key := range []string{"key-1", "key-2"} {
+		if err := solver.Present(newChallengeRequest("_acme-challenge.www.sub.example.com.", "sub.example.com.", key, config)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if records := api.get("sub.example.com", "TXT", "_acme-challenge.www"); len(records) != 2 {
+		t.Fatalf("expected 2 TXT values in zone sub.example.com, got: %d", len(records))
+	}
+
+	if api.domainsCalls != 1 {
+		t.Fatalf("expected the domains to be cached, got %d calls", api.domainsCalls)
+	}
+
+	if err := solver.Present(newChallengeRequest("_acme-challenge.example.org.", "example.org.", "key", config)); err == nil {
+		t.Fatal("expected an error for a domain not managed by the account")
+	}
+
+	api.mu.Lock()
+	api.revoked = map[string]bool{"revoked": true}
+	api.mu.Unlock()
+
+	revoked := fmt.Sprintf(`{"apiKeySecretRef":{"key":"revoked","secret":"secret"},"ttl":600,"apiURL":%q,"zoneResolution":"api"}`, api.URL)
+
+	if err := solver.Present(newChallengeRequest("_acme-challenge.example.com.", "example.com.", "key", revoked)); err == nil || !strings.Contains(err.Error(), "GoDaddy rejected the API credentials") {
+		t.Fatalf("expected the listing of the domains to be explained, got: %v", err)
+	}
+}
+
+func TestExtractRecordName(t *testing.T) {
+	tests := []struct {
+		fqdn, zone, expected string
+	}{
+		{"_acme-challenge.example.com.", "example.com.", "_acme-challenge"},
+		{"_acme-challenge.www.example.com.", "example.com", "_acme-challenge.www"},
+		{"_acme-challenge.example.com.example.com.", "example.com.", "_acme-challenge.example.com"},
+		{"_acme-challenge.Example.COM.", "example.com", "_acme-challenge"},
+		{"example.com.", "example.com.", "@"},
+	}
+
+	for _, test := range tests {
+		if name, err := extractRecordName(test.fqdn, test.zone); err != nil || name != test.expected {
+			t.Errorf("%s in %s: expected %s, got: %s, error: %v", test.fqdn, test.zone, test.expected, name, err)
+		}
+	}
+
+	for _, zone := range []string{"other.com", "ample.com", "www.example.com"} {
+		if _, err := extractRecordName("_acme-challenge.example.com.", zone); err == nil {
+			t.Errorf("expected an error for zone: %s", zone)
+		}
+	}
+}
+
+func TestStaticZone(t *testing.T) {
+	cfg := godaddyDNSProviderConfig{
+		ZoneResolution: zoneResolutionStatic,
+		Zone:           "default.com",
+		Zones: map[string]string{
+			"example.com":          "example.com",
+			"internal.example.com": "example.com",
+			"sub.example.com.":     "sub.example.com.",
+		},
+	}
+
+	tests := map[string]string{
+		"_acme-challenge.example.com.":              "example.com",
+		"_acme-challenge.www.sub.example.com.":      "sub.example.com",
+		"_acme-challenge.app.internal.example.com.": "example.com",
+		"_acme-challenge.notexample.com.":           "default.com",
+	}
+
+	for fqdn, expected := range tests {
+		if zone, err := findStaticZone(cfg, fqdn); err != nil || zone != expected {
+			t.Errorf("%s: expected %s, got: %s, error: %v", fqdn, expected, zone, err)
+		}
+	}
+
+	cfg.Zone = ""
+
+	if _, err := findStaticZone(cfg, "_acme-challenge.other.com."); err == nil {
+		t.Error("expected an error when no zone matches")
+	}
+}
+
+func TestZoneResolutionCertManager(t *testing.T) {
+	stubSolver(t, func(fqdn string, nameservers []string) (string, error) {
+		return "", fmt.Errorf("unexpected DNS lookup of: %s", fqdn)
+	})
+
+	api := newFakeGoDaddy(t)
+
+	solver := &godaddyDNSProviderSolver{}
+	config := fmt.Sprintf(`{"apiKeySecretRef":{"key":"key","secret":"secret"},"ttl":600,"apiURL":%q,"zoneResolution":"certManager"}`, api.URL)
+
+	if err := solver.Present(newChallengeRequest("_acme-challenge.www.example.com.", "example.com.", "key", config)); err != nil {
+		t.Fatal(err)
+	}
+
+	if records := api.get("example.com", "TXT", "_acme-challenge.www"); len(records) != 1 {
+		t.Fatalf("expected 1 TXT value in zone example.com, got: %d", len(records))
+	}
+}